@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"syscall/js"
 
 	"github.com/adzeitor/goscheme/scheme"
@@ -8,10 +9,15 @@ import (
 )
 
 func main() {
+	var output strings.Builder
+	interp := scheme.NewInterpreter(scheme.WithStdout(&output), scheme.WithStderr(&output))
 	js.Global().Set("schemeEval", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		result := scheme.Eval(args[0].String())
-		output := sexpr.Print(result)
-		return output
+		output.Reset()
+		result, err := interp.EvalString(args[0].String())
+		if err != nil {
+			return output.String() + "exception: " + err.Error()
+		}
+		return output.String() + sexpr.Print(result)
 	}))
 	select {} // Code must not finish
 }
@@ -7,5 +7,6 @@ import (
 )
 
 func main() {
-	scheme.RunRepl(os.Stdin, os.Stdout)
+	interp := scheme.NewInterpreter()
+	scheme.RunRepl(interp.Environment(), os.Stdin, os.Stdout)
 }
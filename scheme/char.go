@@ -0,0 +1,146 @@
+package scheme
+
+import (
+	"unicode"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func addCharBuiltins(env *Environment) {
+	AddFuncToEnv(env, "char?", predicateBuiltin("char?", func(e sexpr.Expr) bool {
+		_, ok := e.(sexpr.Char)
+		return ok
+	}))
+	AddFuncToEnv(env, "char->integer", charToIntegerBuiltin)
+	AddFuncToEnv(env, "integer->char", integerToCharBuiltin)
+
+	AddFuncToEnv(env, "char-upcase", charMappingBuiltin("char-upcase", unicode.ToUpper))
+	AddFuncToEnv(env, "char-downcase", charMappingBuiltin("char-downcase", unicode.ToLower))
+	AddFuncToEnv(env, "char-foldcase", charMappingBuiltin("char-foldcase", foldCase))
+
+	AddFuncToEnv(env, "char-alphabetic?", charPredicateBuiltin("char-alphabetic?", unicode.IsLetter))
+	AddFuncToEnv(env, "char-numeric?", charPredicateBuiltin("char-numeric?", unicode.IsDigit))
+	AddFuncToEnv(env, "char-whitespace?", charPredicateBuiltin("char-whitespace?", unicode.IsSpace))
+	AddFuncToEnv(env, "char-upper-case?", charPredicateBuiltin("char-upper-case?", unicode.IsUpper))
+	AddFuncToEnv(env, "char-lower-case?", charPredicateBuiltin("char-lower-case?", unicode.IsLower))
+	AddFuncToEnv(env, "digit-value", digitValueBuiltin)
+
+	AddFuncToEnv(env, "char=?", charCompareBuiltin("char=?", false, func(c int) bool { return c == 0 }))
+	AddFuncToEnv(env, "char<?", charCompareBuiltin("char<?", false, func(c int) bool { return c < 0 }))
+	AddFuncToEnv(env, "char>?", charCompareBuiltin("char>?", false, func(c int) bool { return c > 0 }))
+	AddFuncToEnv(env, "char<=?", charCompareBuiltin("char<=?", false, func(c int) bool { return c <= 0 }))
+	AddFuncToEnv(env, "char>=?", charCompareBuiltin("char>=?", false, func(c int) bool { return c >= 0 }))
+	AddFuncToEnv(env, "char-ci=?", charCompareBuiltin("char-ci=?", true, func(c int) bool { return c == 0 }))
+	AddFuncToEnv(env, "char-ci<?", charCompareBuiltin("char-ci<?", true, func(c int) bool { return c < 0 }))
+	AddFuncToEnv(env, "char-ci>?", charCompareBuiltin("char-ci>?", true, func(c int) bool { return c > 0 }))
+	AddFuncToEnv(env, "char-ci<=?", charCompareBuiltin("char-ci<=?", true, func(c int) bool { return c <= 0 }))
+	AddFuncToEnv(env, "char-ci>=?", charCompareBuiltin("char-ci>=?", true, func(c int) bool { return c >= 0 }))
+}
+
+// checkChars ensures that all arguments of procedure are characters.
+func checkChars(procedure string, args []sexpr.Expr) error {
+	for i, arg := range args {
+		if _, ok := arg.(sexpr.Char); !ok {
+			return errWrongType(arg, i, procedure)
+		}
+	}
+	return nil
+}
+
+// checkCharArgs ensures that procedure is called with count characters.
+func checkCharArgs(procedure string, count int, args []sexpr.Expr) error {
+	if err := checkArity(procedure, count, args); err != nil {
+		return err
+	}
+	return checkChars(procedure, args)
+}
+
+// foldCase maps character to lowercase through uppercase, so characters with
+// several lowercase forms are folded to the same one.
+func foldCase(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+func charToIntegerBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkCharArgs("char->integer", 1, args); err != nil {
+		return nil, err
+	}
+	return int(args[0].(sexpr.Char)), nil
+}
+
+func integerToCharBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("integer->char", 1, args); err != nil {
+		return nil, err
+	}
+	code, ok := args[0].(int)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "integer->char")
+	}
+	if code < 0 || code > unicode.MaxRune || (code >= 0xd800 && code <= 0xdfff) {
+		return nil, errBadRange(args[0], 0, "integer->char")
+	}
+	return sexpr.Char(code), nil
+}
+
+func digitValueBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkCharArgs("digit-value", 1, args); err != nil {
+		return nil, err
+	}
+	r := rune(args[0].(sexpr.Char))
+	if !unicode.IsDigit(r) {
+		return false, nil
+	}
+	// decimal digits are contiguous ranges of ten characters in Unicode
+	zero := r
+	for unicode.IsDigit(zero - 1) {
+		zero--
+	}
+	return int(r-zero) % 10, nil
+}
+
+func charMappingBuiltin(name string, mapping func(rune) rune) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkCharArgs(name, 1, args); err != nil {
+			return nil, err
+		}
+		return sexpr.Char(mapping(rune(args[0].(sexpr.Char)))), nil
+	}
+}
+
+func charPredicateBuiltin(name string, predicate func(rune) bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkCharArgs(name, 1, args); err != nil {
+			return nil, err
+		}
+		return predicate(rune(args[0].(sexpr.Char))), nil
+	}
+}
+
+// charCompareBuiltin makes chained comparison of characters by code points,
+// case insensitive comparison folds case of characters first.
+func charCompareBuiltin(name string, foldCaseFirst bool, holds func(comparison int) bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkMinArity(name, 1, args); err != nil {
+			return nil, err
+		}
+		if err := checkChars(name, args); err != nil {
+			return nil, err
+		}
+		for i := 1; i < len(args); i++ {
+			a, b := rune(args[i-1].(sexpr.Char)), rune(args[i].(sexpr.Char))
+			if foldCaseFirst {
+				a, b = foldCase(a), foldCase(b)
+			}
+			comparison := 0
+			if a < b {
+				comparison = -1
+			} else if a > b {
+				comparison = 1
+			}
+			if !holds(comparison) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
@@ -0,0 +1,69 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestChars(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: `#\a`, want: `#\a`},
+		{in: `'(#\space #\x3bb)`, want: `(#\space #\λ)`},
+		{in: `(char? #\a)`, want: `#t`},
+		{in: `(char? "a")`, want: `#f`},
+		{in: `(char->integer #\newline)`, want: `10`},
+		{in: `(integer->char 955)`, want: `#\λ`},
+		{in: `(char-upcase #\a)`, want: `#\A`},
+		{in: `(char-downcase #\Λ)`, want: `#\λ`},
+		{in: `(char-foldcase #\A)`, want: `#\a`},
+		{in: `(char-alphabetic? #\a)`, want: `#t`},
+		{in: `(char-alphabetic? #\1)`, want: `#f`},
+		{in: `(char-numeric? #\1)`, want: `#t`},
+		{in: `(char-whitespace? #\tab)`, want: `#t`},
+		{in: `(char-upper-case? #\A)`, want: `#t`},
+		{in: `(char-lower-case? #\A)`, want: `#f`},
+		{in: `(digit-value #\7)`, want: `7`},
+		{in: `(digit-value #\x0664)`, want: `4`},
+		{in: `(digit-value #\a)`, want: `#f`},
+		{in: `(char=? #\a #\a #\a)`, want: `#t`},
+		{in: `(char<? #\a #\b #\c)`, want: `#t`},
+		{in: `(char<? #\a #\c #\b)`, want: `#f`},
+		{in: `(char>=? #\b #\b #\a)`, want: `#t`},
+		{in: `(char=? #\a #\A)`, want: `#f`},
+		{in: `(char-ci=? #\a #\A)`, want: `#t`},
+		{in: `(char-ci<? #\a #\B)`, want: `#t`},
+		{in: `(equal? '(#\a) '(#\a))`, want: `#t`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, sexpr.Print(mustEval(t, tt.in)))
+		})
+	}
+}
+
+func TestCharErrors(t *testing.T) {
+	cases := []struct {
+		in   string
+		kind ErrorKind
+	}{
+		{in: `(char->integer "a")`, kind: KindWrongType},
+		{in: `(char<? #\a 1)`, kind: KindWrongType},
+		{in: `(char-upcase)`, kind: KindArity},
+		{in: `(integer->char #\a)`, kind: KindWrongType},
+		{in: `(integer->char -1)`, kind: KindBadRange},
+		{in: `(integer->char 55296)`, kind: KindBadRange},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.kind, evalError(t, tt.in).Kind)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package scheme
+
+import (
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// frame is a part of continuation, a computation which waits for a value.
+// Frames are never modified after they are pushed, so a captured continuation
+// can be resumed any number of times.
+type frame struct {
+	// resume receives the value, nil for the bottom frame of machine.
+	resume func(value sexpr.Expr) (sexpr.Expr, *tailCall, error)
+	next   *frame
+	// winders are dynamic-wind extents the frame is within.
+	winders *winder
+	// handlers are exception handlers installed for computation which
+	// returns to the frame.
+	handlers *handler
+	// depth is the number of frames below including frames of machines
+	// which wait for the machine to return.
+	depth int
+	// machine is the one which owns the bottom frame.
+	machine *machine
+}
+
+// winder is an extent of dynamic-wind, before is called on entering it and
+// after on leaving it. Extents form a tree linked by next.
+type winder struct {
+	before sexpr.Expr
+	after  sexpr.Expr
+	env    *Environment
+	next   *winder
+	depth  int
+	// handlers are those of dynamic-wind call, thunks are called with them.
+	handlers *handler
+}
+
+// Continuation is a captured rest of computation, it is applicable and
+// passes its arguments as the value of call/cc which captured it.
+type Continuation struct {
+	frame *frame
+}
+
+func (*Continuation) String() string {
+	return "#[continuation]"
+}
+
+// continuationJump is returned as error to unwind Go stack to the machine
+// which owns the continuation.
+type continuationJump struct {
+	target *Continuation
+	value  sexpr.Expr
+}
+
+func (*continuationJump) Error() string {
+	return "continuation invoked"
+}
+
+// controlProcedure is a builtin which receives the continuation of its call,
+// its result is evaluated further like result of special form.
+type controlProcedure func(args []sexpr.Expr, k *Continuation, env *Environment) (sexpr.Expr, *tailCall, error)
+
+// machine evaluates expressions keeping the continuation as a chain of frames
+// instead of Go stack. Go code which calls back into Scheme, like builtins
+// that apply procedures, runs a nested machine which continues the Go code
+// with its exception handlers and dynamic-wind extents. Continuations of
+// nested machine can be resumed only until it returns, continuations of
+// toplevel machines are interchangeable, so they may be resumed by later
+// evaluations.
+type machine struct {
+	k        *frame
+	bottom   *frame
+	toplevel bool
+	// interpreter limits evaluation, it is nil when environment is made
+	// without Interpreter.
+	interpreter *Interpreter
+}
+
+// newMachine returns machine which continues caller, the continuation of Go
+// code which runs the machine. Toplevel machines have no caller.
+func newMachine(env *Environment, caller *frame, toplevel bool) *machine {
+	m := &machine{toplevel: toplevel, interpreter: env.interpreter}
+	m.bottom = &frame{handlers: noHandlers, machine: m}
+	if caller != nil {
+		m.bottom.winders = caller.winders
+		m.bottom.handlers = caller.handlers
+		m.bottom.depth = caller.depth + 1
+	}
+	m.k = m.bottom
+	return m
+}
+
+// runNested runs tail call by nested machine for Go code called by the
+// innermost running machine of the interpreter.
+func runNested(tail *tailCall) (sexpr.Expr, error) {
+	interp := tail.Env.interpreter
+	if interp == nil {
+		return newMachine(tail.Env, nil, false).run(tail)
+	}
+	caller := interp.current
+	defer func() {
+		interp.current = caller
+	}()
+	return newMachine(tail.Env, caller, false).run(tail)
+}
+
+// evalToplevel evaluates expression by toplevel machine.
+func evalToplevel(expr sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	return runToplevel(&tailCall{Expr: expr, Env: env})
+}
+
+// runToplevel runs tail call by toplevel machine, limits of interpreter are
+// counted afresh. Uncaught exceptions are reported as *Error.
+func runToplevel(tail *tailCall) (sexpr.Expr, error) {
+	m := newMachine(tail.Env, nil, true)
+	if m.interpreter != nil {
+		m.interpreter.steps = 0
+	}
+	result, err := m.run(tail)
+	if _, ok := err.(*continuationJump); ok {
+		return nil, newError(KindNotApplicable, "The continuation can not be resumed after return of builtin procedure:", err.(*continuationJump).target)
+	}
+	return result, raisedError(err)
+}
+
+func (m *machine) run(tail *tailCall) (sexpr.Expr, error) {
+	var value sexpr.Expr
+	var err error
+	for {
+		switch {
+		case err != nil:
+			if e, ok := err.(*Error); ok && e.Kind != KindLimit && m.k.handlers != noHandlers {
+				value, tail, err = raise(e.condition(), false, m.k.handlers)
+				continue
+			}
+			jump, ok := err.(*continuationJump)
+			if !ok || !m.owns(jump.target) {
+				if unwindErr := m.rewind(m.bottom.winders); unwindErr != nil {
+					return nil, unwindErr
+				}
+				return nil, err
+			}
+			if err = m.rewind(jump.target.frame.winders); err == nil {
+				m.k = jump.target.frame
+				value = jump.value
+			}
+		case tail != nil:
+			if tail.Then != nil {
+				m.push(tail.Then, tail.Wind, tail.Handlers)
+			}
+			if m.interpreter != nil {
+				m.interpreter.current = m.k
+				if err = m.interpreter.checkLimits(m.k); err != nil {
+					continue
+				}
+			}
+			value, tail, err = m.step(tail)
+		case m.k.resume == nil:
+			return value, nil
+		default:
+			f := m.k
+			m.k = f.next
+			if m.interpreter != nil {
+				m.interpreter.current = m.k
+			}
+			value, tail, err = f.resume(value)
+		}
+	}
+}
+
+// push pushes frame, wind is set when the frame leaves dynamic-wind extent and
+// handlers when computation returning to the frame has other exception
+// handlers.
+func (m *machine) push(resume func(sexpr.Expr) (sexpr.Expr, *tailCall, error), wind *winder, handlers *handler) {
+	if handlers == nil {
+		handlers = m.k.handlers
+	}
+	winders := m.k.winders
+	if wind != nil {
+		depth := 1
+		if winders != nil {
+			depth = winders.depth + 1
+		}
+		winders = &winder{before: wind.before, after: wind.after, env: wind.env, next: winders, depth: depth, handlers: handlers}
+	}
+	m.k = &frame{resume: resume, next: m.k, winders: winders, handlers: handlers, depth: m.k.depth + 1, machine: m.k.machine}
+}
+
+func (m *machine) step(tail *tailCall) (sexpr.Expr, *tailCall, error) {
+	if tail.Procedure == nil {
+		form, ok := tail.Expr.(*sexpr.Pair)
+		if !ok {
+			value, err := evalAtom(tail.Expr, tail.Env)
+			return value, nil, err
+		}
+		return evalList(form, tail.Env)
+	}
+	if control, ok := tail.Procedure.(controlProcedure); ok {
+		return control(tail.Arguments, &Continuation{frame: m.k}, tail.Env)
+	}
+	return apply(tail.Procedure, tail.Arguments, tail.Env)
+}
+
+// owns reports whether the continuation can be resumed by the machine.
+func (m *machine) owns(k *Continuation) bool {
+	owner := k.frame.machine
+	return owner == m || owner.toplevel && m.toplevel
+}
+
+// rewind calls after thunks of extents which are left and before thunks of
+// extents which are entered on the way from the current continuation to one
+// within winders.
+func (m *machine) rewind(winders *winder) error {
+	from := m.k.winders
+	common := commonWinder(from, winders)
+	for w := from; w != common; w = w.next {
+		if _, err := m.callThunk(w.after, w); err != nil {
+			return err
+		}
+	}
+	var entered []*winder
+	for w := winders; w != common; w = w.next {
+		entered = append(entered, w)
+	}
+	for i := len(entered) - 1; i >= 0; i-- {
+		if _, err := m.callThunk(entered[i].before, entered[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commonWinder returns the innermost extent which contains both a and b.
+func commonWinder(a *winder, b *winder) *winder {
+	for a != b {
+		if b == nil || a != nil && a.depth > b.depth {
+			a = a.next
+		} else {
+			b = b.next
+		}
+	}
+	return a
+}
+
+// callThunk calls before or after thunk of extent w by nested machine outside
+// of the extent.
+func (m *machine) callThunk(thunk sexpr.Expr, w *winder) (sexpr.Expr, error) {
+	caller := &frame{winders: w.next, handlers: w.handlers, depth: m.k.depth}
+	return newMachine(w.env, caller, false).run(&tailCall{Procedure: thunk, Env: w.env})
+}
+
+func addContinuationBuiltins(env *Environment) {
+	addControlToEnv(env, "call-with-current-continuation", callCCBuiltin)
+	addControlToEnv(env, "call/cc", callCCBuiltin)
+	addControlToEnv(env, "dynamic-wind", dynamicWindBuiltin)
+}
+
+func callCCBuiltin(args []sexpr.Expr, k *Continuation, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if err := checkArity("call-with-current-continuation", 1, args); err != nil {
+		return nil, nil, err
+	}
+	return nil, &tailCall{Procedure: args[0], Arguments: []sexpr.Expr{k}, Env: env}, nil
+}
+
+// dynamicWindBuiltin calls (dynamic-wind before thunk after), the after thunk
+// is called whenever the call of thunk is left, including leaving by
+// continuations, and before whenever it is entered.
+func dynamicWindBuiltin(args []sexpr.Expr, k *Continuation, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if err := checkArity("dynamic-wind", 3, args); err != nil {
+		return nil, nil, err
+	}
+	before, thunk, after := args[0], args[1], args[2]
+	for i, procedure := range args {
+		if !isProcedure(procedure) {
+			return nil, nil, errWrongType(procedure, i, "dynamic-wind")
+		}
+	}
+	enter := func(sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		leave := func(result sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+			return nil, &tailCall{Procedure: after, Env: env, Then: func(sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+				return result, nil, nil
+			}}, nil
+		}
+		wind := &winder{before: before, after: after, env: env}
+		return nil, &tailCall{Procedure: thunk, Env: env, Then: leave, Wind: wind}, nil
+	}
+	return nil, &tailCall{Procedure: before, Env: env, Then: enter}, nil
+}
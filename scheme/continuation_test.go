@@ -0,0 +1,219 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestContinuations(t *testing.T) {
+	t.Run("escape", func(t *testing.T) {
+		assert.Equal(t, 3, mustEval(t, `(+ 1 (call/cc (lambda (k) (+ 10 (k 2)))))`))
+		assert.Equal(t, 5, mustEval(t, `(call-with-current-continuation (lambda (k) 5))`))
+		assert.Equal(t, sexpr.List(1, 2), mustEval(t, `
+			(call-with-values
+				(lambda () (call/cc (lambda (k) (k 1 2))))
+				list)`))
+	})
+
+	t.Run("early exit from deep recursion", func(t *testing.T) {
+		// arrange
+		// non-tail recursion is limited by the depth limit, not by Go stack
+		interp := NewInterpreter(WithDepthLimit(1000000))
+		_, err := interp.EvalString(`
+			(define (product-of-list numbers)
+				(call/cc
+					(lambda (return)
+						(let loop ((numbers numbers))
+							(cond
+								((null? numbers) 1)
+								((= (car numbers) 0) (return 0))
+								(else (* (car numbers) (loop (cdr numbers)))))))))
+			(define (count-down n)
+				(if (= n 0) (list 0) (cons n (count-down (- n 1)))))`)
+		assert.NoError(t, err)
+
+		// act
+		small, err := interp.EvalString(`(product-of-list '(1 2 3 4))`)
+		assert.NoError(t, err)
+		large, err := interp.EvalString(`(product-of-list (count-down 100000))`)
+		assert.NoError(t, err)
+
+		// assert
+		assert.Equal(t, 24, small)
+		assert.Equal(t, 0, large)
+	})
+
+	t.Run("escape through builtin procedure", func(t *testing.T) {
+		assert.Equal(t, sexpr.Symbol("found"), mustEval(t, `
+			(call/cc
+				(lambda (k)
+					(vector-map (lambda (x) (if (= x 2) (k 'found) x)) #(1 2 3))))`))
+	})
+
+	t.Run("re-entry", func(t *testing.T) {
+		assert.Equal(t, sexpr.List(3, 4), mustEval(t, `
+			(let ((n 0) (k #f))
+				(let ((v (call/cc (lambda (c) (set! k c) 0))))
+					(set! n (+ n 1))
+					(if (< v 3) (k (+ v 1)) (list v n))))`))
+	})
+
+	t.Run("re-entry by later evaluation", func(t *testing.T) {
+		// arrange
+		mustEval(t, `(define saved-k #f)`)
+		assert.Equal(t, sexpr.List(1, 2), mustEval(t, `(list 1 (call/cc (lambda (k) (set! saved-k k) 2)))`))
+
+		// act
+		result := mustEval(t, `(saved-k 10)`)
+
+		// assert
+		assert.Equal(t, sexpr.List(1, 10), result)
+	})
+
+	t.Run("generator", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define (make-generator items)
+				(define return #f)
+				(define resume
+					(lambda ()
+						(let loop ((items items))
+							(when (pair? items)
+								(call/cc
+									(lambda (next)
+										(set! resume (lambda () (next #f)))
+										(return (car items))))
+								(loop (cdr items))))
+						(return 'done)))
+				(lambda ()
+					(call/cc
+						(lambda (k)
+							(set! return k)
+							(resume)))))`)
+		mustEval(t, `(define generate (make-generator '(a b c)))`)
+
+		// assert
+		assert.Equal(t, "(a b c done done)", sexpr.Print(mustEval(t, `
+			(list (generate) (generate) (generate) (generate) (generate))`)))
+	})
+
+	t.Run("tree walking generators compare fringes", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define (tree-walker tree)
+				(define caller #f)
+				(define (walk tree)
+					(cond
+						((null? tree) #t)
+						((pair? tree) (walk (car tree)) (walk (cdr tree)))
+						(else (call/cc
+							(lambda (rest)
+								(set! resume (lambda () (rest #f)))
+								(caller tree))))))
+				(define (resume)
+					(walk tree)
+					(caller '()))
+				(lambda ()
+					(call/cc (lambda (k) (set! caller k) (resume)))))`)
+		mustEval(t, `
+			(define (same-fringe? a b)
+				(let ((next-a (tree-walker a))
+				      (next-b (tree-walker b)))
+					(let loop ()
+						(let ((leaf-a (next-a)) (leaf-b (next-b)))
+							(cond
+								((not (eqv? leaf-a leaf-b)) #f)
+								((null? leaf-a) #t)
+								(else (loop)))))))`)
+
+		// assert
+		assert.Equal(t, true, mustEval(t, `(same-fringe? '(1 (2 3) ((4))) '((1 2) (3 4)))`))
+		assert.Equal(t, false, mustEval(t, `(same-fringe? '(1 (2 3)) '(1 (2 4)))`))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assert.Equal(t, KindNotApplicable, evalError(t, `(call/cc 1)`).Kind)
+		assert.Equal(t, KindArity, evalError(t, `(call/cc)`).Kind)
+		assert.Equal(t, KindArity, evalError(t, `(call/cc (lambda () 1))`).Kind)
+	})
+
+	t.Run("continuation captured by builtin can not be resumed after it returns", func(t *testing.T) {
+		// arrange
+		mustEval(t, `(define builtin-k #f)`)
+		mustEval(t, `(vector-map (lambda (x) (call/cc (lambda (k) (set! builtin-k k) x))) #(1))`)
+
+		// act
+		err := evalError(t, `(builtin-k 5)`)
+
+		// assert
+		assert.Equal(t, KindNotApplicable, err.Kind)
+	})
+}
+
+func TestDynamicWind(t *testing.T) {
+	t.Run("thunks are called in order", func(t *testing.T) {
+		assert.Equal(t, "(3 (after during before))", sexpr.Print(mustEval(t, `
+			(let* ((path '())
+			       (add (lambda (s) (set! path (cons s path))))
+			       (result (dynamic-wind
+			                 (lambda () (add 'before))
+			                 (lambda () (add 'during) 3)
+			                 (lambda () (add 'after)))))
+				(list result path))`)))
+	})
+
+	t.Run("leaving and entering by continuations", func(t *testing.T) {
+		assert.Equal(t, "(disconnect talk2 connect disconnect talk1 connect)", sexpr.Print(mustEval(t, `
+			(let ((path '()) (c #f) (count 0))
+				(let ((add (lambda (s) (set! path (cons s path)))))
+					(dynamic-wind
+						(lambda () (add 'connect))
+						(lambda () (add (call/cc (lambda (c0) (set! c c0) 'talk1))))
+						(lambda () (add 'disconnect)))
+					(set! count (+ count 1))
+					(if (< count 2)
+						(c 'talk2)
+						path)))`)))
+	})
+
+	t.Run("escape calls after thunks of nested extents", func(t *testing.T) {
+		assert.Equal(t, "(escaped (outer-after inner-after))", sexpr.Print(mustEval(t, `
+			(let* ((path '())
+			       (add (lambda (s) (set! path (cons s path))))
+			       (result (call/cc
+			                 (lambda (k)
+			                   (dynamic-wind
+			                     (lambda () #f)
+			                     (lambda ()
+			                       (dynamic-wind
+			                         (lambda () #f)
+			                         (lambda () (k 'escaped))
+			                         (lambda () (add 'inner-after))))
+			                     (lambda () (add 'outer-after)))))))
+				(list result path))`)))
+	})
+
+	t.Run("error calls after thunk", func(t *testing.T) {
+		// arrange
+		mustEval(t, `(define wind-log '())`)
+
+		// act
+		err := evalError(t, `
+			(dynamic-wind
+				(lambda () #f)
+				(lambda () (car '()))
+				(lambda () (set! wind-log (cons 'after wind-log))))`)
+
+		// assert
+		assert.Equal(t, KindWrongType, err.Kind)
+		assert.Equal(t, sexpr.List(sexpr.Symbol("after")), mustEval(t, `wind-log`))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assert.Equal(t, KindWrongType, evalError(t, `(dynamic-wind 1 (lambda () 1) (lambda () 1))`).Kind)
+		assert.Equal(t, KindArity, evalError(t, `(dynamic-wind (lambda () 1))`).Kind)
+	})
+}
@@ -0,0 +1,225 @@
+package scheme
+
+import (
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// evalAnd evaluates (and test...) from left to right until a test is false,
+// the last test is in tail position.
+func evalAnd(list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	return evalTests(list[1:], env, true)
+}
+
+// evalOr evaluates (or test...) from left to right until a test is true, the
+// last test is in tail position.
+func evalOr(list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	return evalTests(list[1:], env, false)
+}
+
+// evalTests evaluates tests of and (when all is set) or or until one of them
+// decides the result.
+func evalTests(tests []sexpr.Expr, env *Environment, all bool) (sexpr.Expr, *tailCall, error) {
+	switch len(tests) {
+	case 0:
+		return all, nil, nil
+	case 1:
+		return nil, &tailCall{Expr: tests[0], Env: env}, nil
+	}
+	return evalThen(tests[0], env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		if isTrue(value) != all {
+			return value, nil, nil
+		}
+		return evalTests(tests[1:], env, all)
+	})
+}
+
+// evalWhen evaluates (when test body...) and (unless test body...), the body
+// is evaluated when the test is equal to expected.
+func evalWhen(form sexpr.Expr, list []sexpr.Expr, env *Environment, expected bool) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	return evalThen(list[1], env, func(test sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		if isTrue(test) != expected {
+			return nil, nil, nil
+		}
+		return evalSequence(list[2:], env)
+	})
+}
+
+// evalClauseBody evaluates body of cond or case clause which is selected by
+// value. The body is either expressions or `=> receiver` where receiver is
+// called with the value.
+func evalClauseBody(form sexpr.Expr, body []sexpr.Expr, value sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(body) == 0 || syntaxKeyword(body[0], env) != "=>" {
+		return evalSequence(body, env)
+	}
+	if len(body) != 2 {
+		return nil, nil, errIllFormed(form)
+	}
+	return evalThen(body[1], env, func(receiver sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return apply(receiver, []sexpr.Expr{value}, env)
+	})
+}
+
+// evalCond evaluates (cond clause...) where clause is (test body...),
+// (test => receiver), (test) which returns the value of the test, or the last
+// (else body...).
+func evalCond(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	return evalCondClauses(form, list[1:], env, func() (sexpr.Expr, *tailCall, error) {
+		err := newError(KindNoMatch, "No matching clause in cond")
+		err.Expr = form
+		return nil, nil, err
+	})
+}
+
+// evalCondClauses evaluates clauses of cond, otherwise is called when none of
+// them is selected.
+func evalCondClauses(form sexpr.Expr, clauses []sexpr.Expr, env *Environment, otherwise func() (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	if len(clauses) == 0 {
+		return otherwise()
+	}
+	clause, ok := sexpr.ToSlice(clauses[0])
+	if !ok || len(clause) == 0 {
+		return nil, nil, errIllFormed(form)
+	}
+	if syntaxKeyword(clause[0], env) == "else" {
+		if len(clause) == 1 {
+			return nil, nil, errIllFormed(form)
+		}
+		return evalClauseBody(form, clause[1:], true, env)
+	}
+
+	return evalThen(clause[0], env, func(match sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		if !isTrue(match) {
+			return evalCondClauses(form, clauses[1:], env, otherwise)
+		}
+		if len(clause) == 1 {
+			return match, nil, nil
+		}
+		return evalClauseBody(form, clause[1:], match, env)
+	})
+}
+
+// evalCase evaluates (case key clause...) where clause is
+// ((datum...) body...) selected when the key is eqv? to one of the datums, or
+// the last (else body...). Like in cond the body may be `=> receiver`, which
+// is called with the key.
+func evalCase(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 2 {
+		return nil, nil, errIllFormed(form)
+	}
+	return evalThen(list[1], env, func(key sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		for _, clause := range list[2:] {
+			clause, ok := sexpr.ToSlice(clause)
+			if !ok || len(clause) < 2 {
+				return nil, nil, errIllFormed(form)
+			}
+			if syntaxKeyword(clause[0], env) == "else" {
+				return evalClauseBody(form, clause[1:], key, env)
+			}
+			datums, ok := sexpr.ToSlice(stripSyntax(clause[0]))
+			if !ok {
+				return nil, nil, errIllFormed(form)
+			}
+			for _, datum := range datums {
+				if isEqv(key, datum) {
+					return evalClauseBody(form, clause[1:], key, env)
+				}
+			}
+		}
+		return nil, nil, nil
+	})
+}
+
+// doVariable is a parsed (name init step) of do loop, step may be omitted.
+type doVariable struct {
+	Name sexpr.Symbol
+	Init sexpr.Expr
+	Step sexpr.Expr
+}
+
+// evalDo evaluates the iteration
+// (do ((name init step)...) (test result...) command...). Every iteration
+// binds variables afresh, so closures made by commands keep their values.
+func evalDo(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	specs, ok := sexpr.ToSlice(list[1])
+	if !ok {
+		return nil, nil, errIllFormed(form)
+	}
+	variables := make([]doVariable, len(specs))
+	seen := make(map[sexpr.Symbol]bool, len(specs))
+	for i, spec := range specs {
+		parts, ok := sexpr.ToSlice(spec)
+		if !ok || len(parts) != 2 && len(parts) != 3 {
+			return nil, nil, errIllFormed(form)
+		}
+		name, ok := bindingName(parts[0])
+		if !ok || seen[name] {
+			return nil, nil, errIllFormed(form)
+		}
+		seen[name] = true
+		variables[i] = doVariable{Name: name, Init: parts[1]}
+		if len(parts) == 3 {
+			variables[i].Step = parts[2]
+		}
+	}
+	exit, ok := sexpr.ToSlice(list[2])
+	if !ok || len(exit) == 0 {
+		return nil, nil, errIllFormed(form)
+	}
+	commands := list[3:]
+
+	inits := make([]sexpr.Expr, len(variables))
+	for i, variable := range variables {
+		inits[i] = variable.Init
+	}
+	return evalEach(inits, make([]sexpr.Expr, 0, len(inits)), env, func(values []sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return doIteration(variables, exit, commands, values, env)
+	})
+}
+
+// doIteration binds variables of do loop to values, evaluates the exit test
+// and either results or commands and steps for the next iteration.
+func doIteration(variables []doVariable, exit []sexpr.Expr, commands []sexpr.Expr, values []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	loopEnv := env.Extend()
+	var steps []sexpr.Expr
+	for i, variable := range variables {
+		loopEnv.Define(variable.Name, values[i])
+		if variable.Step != nil {
+			steps = append(steps, variable.Step)
+		}
+	}
+	return evalThen(exit[0], loopEnv, func(test sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		if isTrue(test) {
+			return evalSequence(exit[1:], loopEnv)
+		}
+		return evalCommands(commands, loopEnv, func() (sexpr.Expr, *tailCall, error) {
+			return evalEach(steps, make([]sexpr.Expr, 0, len(steps)), loopEnv, func(stepped []sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+				next := make([]sexpr.Expr, len(variables))
+				for i, variable := range variables {
+					if variable.Step == nil {
+						// commands may assign the variable
+						next[i], _ = loopEnv.Lookup(variable.Name)
+						continue
+					}
+					next[i], stepped = stepped[0], stepped[1:]
+				}
+				return doIteration(variables, exit, commands, next, env)
+			})
+		})
+	})
+}
+
+// evalCommands evaluates expressions for their effect and continues with then.
+func evalCommands(commands []sexpr.Expr, env *Environment, then func() (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	if len(commands) == 0 {
+		return then()
+	}
+	return evalThen(commands[0], env, func(sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return evalCommands(commands[1:], env, then)
+	})
+}
@@ -0,0 +1,110 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestControl(t *testing.T) {
+	t.Run("and", func(t *testing.T) {
+		assert.Equal(t, true, mustEval(t, `(and)`))
+		assert.Equal(t, 3, mustEval(t, `(and 1 2 3)`))
+		assert.Equal(t, false, mustEval(t, `(and 1 #f 3)`))
+		// evaluation stops at the first false test
+		assert.Equal(t, false, mustEval(t, `(and #f (car '()))`))
+	})
+
+	t.Run("or", func(t *testing.T) {
+		assert.Equal(t, false, mustEval(t, `(or)`))
+		assert.Equal(t, 1, mustEval(t, `(or #f 1 2)`))
+		assert.Equal(t, false, mustEval(t, `(or #f #f)`))
+		// evaluation stops at the first true test
+		assert.Equal(t, sexpr.List(2), mustEval(t, `(or (cdr '(1 2)) (car '()))`))
+	})
+
+	t.Run("when and unless", func(t *testing.T) {
+		assert.Equal(t, 2, mustEval(t, `(when (= 1 1) 1 2)`))
+		assert.Equal(t, nil, mustEval(t, `(when #f (car '()))`))
+		assert.Equal(t, 2, mustEval(t, `(unless #f 1 2)`))
+		assert.Equal(t, nil, mustEval(t, `(unless 0 (car '()))`))
+		assert.Equal(t, KindSyntax, evalError(t, `(when #t)`).Kind)
+	})
+
+	t.Run("cond clauses", func(t *testing.T) {
+		assert.Equal(t, 3, mustEval(t, `(cond (#f 1) (#t 2 3))`))
+		// clause without body returns value of the test
+		assert.Equal(t, sexpr.List(2), mustEval(t, `(cond (#f 1) ((cdr '(1 2))))`))
+		assert.Equal(t, sexpr.List(2), mustEval(t, `(cond ((assv 'b '((a 1) (b 2))) => cdr) (else #f))`))
+		assert.Equal(t, 4, mustEval(t, `(cond (#f 1) (else => (lambda (x) 4)))`))
+		assert.Equal(t, KindSyntax, evalError(t, `(cond (#t =>))`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(cond (else))`).Kind)
+	})
+
+	t.Run("case", func(t *testing.T) {
+		assert.Equal(t, sexpr.Symbol("composite"), mustEval(t, `
+			(case (* 2 3)
+				((2 3 5 7) 'prime)
+				((1 4 6 8 9) 'composite))`))
+		assert.Equal(t, sexpr.Symbol("consonant"), mustEval(t, `
+			(case (car '(c d))
+				((a e i o u) 'vowel)
+				((w y) 'semivowel)
+				(else => (lambda (x) 'consonant)))`))
+		assert.Equal(t, 16, mustEval(t, `(case 4 ((1 2) 0) ((3 4) => (lambda (x) (* x x))))`))
+		assert.Equal(t, sexpr.Char('b'), mustEval(t, `(case #\b ((#\a) 1) (else #\b))`))
+		assert.Equal(t, nil, mustEval(t, `(case 10 ((1) 1))`))
+		assert.Equal(t, KindSyntax, evalError(t, `(case 1 (1 2))`).Kind)
+	})
+
+	t.Run("do", func(t *testing.T) {
+		assert.Equal(t, "#(0 1 2 3 4)", sexpr.Print(mustEval(t, `
+			(do ((vec (make-vector 5))
+			     (i 0 (+ i 1)))
+				((= i 5) vec)
+				(vector-set! vec i i))`)))
+		assert.Equal(t, 25, mustEval(t, `
+			(let ((x '(1 3 5 7 9)))
+				(do ((x x (cdr x))
+				     (sum 0 (+ sum (car x))))
+					((null? x) sum)))`))
+		// every iteration has its own bindings
+		assert.Equal(t, sexpr.List(2, 1, 0), mustEval(t, `
+			(do ((i 0 (+ i 1))
+			     (getters '() (cons (lambda () i) getters)))
+				((= i 3) (list ((car getters)) ((car (cdr getters))) ((car (cdr (cdr getters)))))))`))
+		assert.Equal(t, KindSyntax, evalError(t, `(do ((i 0 1 2)) (#t))`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(do ((i 0)) ())`).Kind)
+	})
+
+	t.Run("tail positions run in constant stack", func(t *testing.T) {
+		// arrange
+		interp := NewInterpreter(WithDepthLimit(100))
+		_, err := interp.EvalString(`
+			(define (count-and n)
+				(and #t (if (= n 0) 'done (count-and (- n 1)))))
+			(define (count-or n)
+				(or #f (if (= n 0) 'done (count-or (- n 1)))))
+			(define (count-when n)
+				(when #t (if (= n 0) 'done (count-when (- n 1)))))
+			(define (count-unless n)
+				(unless #f (if (= n 0) 'done (count-unless (- n 1)))))
+			(define (count-arrow n)
+				(cond ((= n 0) 'done) (n => (lambda (n) (count-arrow (- n 1))))))
+			(define (count-case n)
+				(case n ((0) 'done) (else (count-case (- n 1)))))`)
+		assert.NoError(t, err)
+
+		// assert
+		for _, name := range []string{"and", "or", "when", "unless", "arrow", "case"} {
+			result, err := interp.EvalString(`(count-` + name + ` 100000)`)
+			assert.NoError(t, err, name)
+			assert.Equal(t, sexpr.Symbol("done"), result, name)
+		}
+		result, err := interp.EvalString(`(do ((i 0 (+ i 1))) ((= i 100000) i))`)
+		assert.NoError(t, err)
+		assert.Equal(t, 100000, result)
+	})
+}
@@ -0,0 +1,73 @@
+package scheme
+
+import (
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// Environment is a frame of variable bindings with a pointer to the enclosing
+// frame. Procedures capture the frame they are created in by reference, so
+// closures see later definitions and share assignments made by set!.
+type Environment struct {
+	vars   map[sexpr.Symbol]sexpr.Expr
+	parent *Environment
+	// interpreter owns the environment, nil when the environment is made
+	// without Interpreter.
+	interpreter *Interpreter
+}
+
+// EmptyEnvironment returns top level environment without any bindings.
+func EmptyEnvironment() *Environment {
+	return &Environment{vars: make(map[sexpr.Symbol]sexpr.Expr)}
+}
+
+// DefaultEnvironment returns top level environment with builtin procedures,
+// it is the global environment of a new Interpreter.
+func DefaultEnvironment() *Environment {
+	return NewInterpreter().Environment()
+}
+
+// Extend returns a new empty frame enclosed by env.
+func (env *Environment) Extend() *Environment {
+	return &Environment{
+		vars:        make(map[sexpr.Symbol]sexpr.Expr),
+		parent:      env,
+		interpreter: env.interpreter,
+	}
+}
+
+// Define binds name in this frame, shadowing bindings of enclosing frames.
+func (env *Environment) Define(name sexpr.Symbol, value sexpr.Expr) {
+	env.vars[name] = value
+}
+
+// Lookup finds value of name in the nearest frame which binds it.
+func (env *Environment) Lookup(name sexpr.Symbol) (sexpr.Expr, bool) {
+	for frame := env; frame != nil; frame = frame.parent {
+		if value, ok := frame.vars[name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// frameOf returns the nearest frame which binds name, nil when it is unbound.
+func (env *Environment) frameOf(name sexpr.Symbol) *Environment {
+	for frame := env; frame != nil; frame = frame.parent {
+		if _, ok := frame.vars[name]; ok {
+			return frame
+		}
+	}
+	return nil
+}
+
+// Set assigns value to name in the frame which binds it and reports whether
+// such frame exists.
+func (env *Environment) Set(name sexpr.Symbol, value sexpr.Expr) bool {
+	for frame := env; frame != nil; frame = frame.parent {
+		if _, ok := frame.vars[name]; ok {
+			frame.vars[name] = value
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package scheme
+
+import (
+	"strings"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// ErrorKind classifies errors signalled during evaluation.
+type ErrorKind string
+
+const (
+	KindSyntax          ErrorKind = "syntax-error"
+	KindUnboundVariable ErrorKind = "unbound-variable"
+	KindWrongType       ErrorKind = "wrong-type-argument"
+	KindNotApplicable   ErrorKind = "inapplicable-object"
+	KindArity           ErrorKind = "wrong-number-of-arguments"
+	KindBadRange        ErrorKind = "bad-range-argument"
+	KindDivideByZero    ErrorKind = "divide-by-zero"
+	KindNoMatch         ErrorKind = "no-match"
+	// KindSimpleError is signalled by the error procedure.
+	KindSimpleError ErrorKind = "simple-error"
+	// KindHandlerReturned is signalled when exception handler returns from
+	// non-continuable raise.
+	KindHandlerReturned ErrorKind = "handler-returned"
+	// KindLimit is signalled when evaluation exceeds a limit of Interpreter,
+	// handlers are not called for it.
+	KindLimit ErrorKind = "limit-exceeded"
+)
+
+// Error is an error signalled by the evaluator, a special form or a builtin.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	// Irritants are the objects that caused the error, they are printed after
+	// the message.
+	Irritants []sexpr.Expr
+	// Expr is the expression which evaluation failed (may be nil).
+	Expr sexpr.Expr
+	// Raised is the object passed to raise which is not an error object and
+	// which no handler took care of (may be nil).
+	Raised sexpr.Expr
+}
+
+func (e *Error) Error() string {
+	if len(e.Irritants) == 0 {
+		return e.Message
+	}
+	parts := make([]string, 0, len(e.Irritants)+1)
+	parts = append(parts, e.Message)
+	for _, irritant := range e.Irritants {
+		parts = append(parts, sexpr.Print(irritant))
+	}
+	return strings.Join(parts, " ")
+}
+
+// String prints error as Scheme object, errors are raised as conditions.
+func (e *Error) String() string {
+	return "#[condition " + string(e.Kind) + "]"
+}
+
+func newError(kind ErrorKind, message string, irritants ...sexpr.Expr) *Error {
+	return &Error{
+		Kind:      kind,
+		Message:   message,
+		Irritants: irritants,
+	}
+}
+
+func errUnboundVariable(name sexpr.Symbol) *Error {
+	err := newError(KindUnboundVariable, "Unbound variable:", name)
+	err.Expr = name
+	return err
+}
+
+func errIllFormed(form sexpr.Expr) *Error {
+	err := newError(KindSyntax, "Ill-formed special form:", form)
+	err.Expr = form
+	return err
+}
+
+func errNotApplicable(object sexpr.Expr) *Error {
+	return newError(KindNotApplicable, "The object is not applicable:", object)
+}
+
+// errArity reports that procedure received count arguments, expected
+// describes how many arguments it accepts, for example "2" or "at least 1".
+func errArity(procedure string, expected string, count int) *Error {
+	return newError(
+		KindArity,
+		"Wrong number of arguments passed to "+procedure+", expected "+expected+", got:",
+		count,
+	)
+}
+
+var ordinals = []string{
+	"first", "second", "third", "fourth", "fifth",
+	"sixth", "seventh", "eighth", "ninth", "tenth",
+}
+
+// errWrongType reports that argument with index position (starting from zero)
+// of procedure has incorrect type.
+func errWrongType(object sexpr.Expr, position int, procedure string) *Error {
+	ordinal := "next"
+	if position < len(ordinals) {
+		ordinal = ordinals[position]
+	}
+	return newError(
+		KindWrongType,
+		"The object, passed as the "+ordinal+" argument to "+procedure+", is not the correct type:",
+		object,
+	)
+}
+
+// errBadRange reports that argument with index position (starting from zero)
+// of procedure has correct type but is out of allowed range.
+func errBadRange(object sexpr.Expr, position int, procedure string) *Error {
+	ordinal := "next"
+	if position < len(ordinals) {
+		ordinal = ordinals[position]
+	}
+	return newError(
+		KindBadRange,
+		"The object, passed as the "+ordinal+" argument to "+procedure+", is not in the correct range:",
+		object,
+	)
+}
+
+func errDivideByZero(procedure string) *Error {
+	return newError(KindDivideByZero, "Division by zero signalled by "+procedure+".")
+}
+
+// withExpr attaches offending expression to err if it is an *Error without one.
+func withExpr(err error, expr sexpr.Expr) error {
+	if e, ok := err.(*Error); ok && e.Expr == nil {
+		e.Expr = expr
+	}
+	return err
+}
@@ -1,239 +1,387 @@
 package scheme
 
 import (
-	"fmt"
-	"strings"
+	"math/big"
 
 	"github.com/adzeitor/goscheme/sexpr"
 )
 
-var globalEnvironment = DefaultEnvironment()
+// Procedure is a procedure implemented in Go, it receives evaluated
+// arguments.
+type Procedure func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error)
 
-type Builtin func(args []sexpr.Expr, env Environment) sexpr.Expr
+// Primitive is a named procedure implemented in Go. Go functions are defined
+// wrapped in primitives, so procedures are printed with their names and
+// compared by identity.
+type Primitive struct {
+	Name      sexpr.Symbol
+	Procedure Procedure
+	// control is set instead of Procedure for procedures which receive
+	// the continuation of their call.
+	control controlProcedure
+}
 
-// Eval evaluate expression in global environment. Be careful with several
-// instances, in this case use EvalInEnvironment.
-func Eval(s string) sexpr.Expr {
-	result, newEnvironment := EvalInEnvironment(s, globalEnvironment)
-	globalEnvironment = newEnvironment
-	return result
+func (p *Primitive) String() string {
+	return "#[procedure " + string(p.Name) + "]"
 }
 
-func EvalInEnvironment(s string, env Environment) (result sexpr.Expr, resultEnv Environment) {
-	// FIXME: is exceptions here good idea? Maybe move to repl or exception free code?
-	defer func() {
-		err := recover()
-		if err != nil {
-			result = fmt.Sprintf("exception: %v", err)
-			// FIXME: good?
-			resultEnv = env
-		}
-	}()
+var defaultInterpreter = NewInterpreter()
 
-	parsed, _, ok := sexpr.Parse(s)
-	if !ok {
-		return "parse error", env
-	}
-	result = eval(parsed, env)
-	return result, env
+// Eval evaluates the first expression of s by interpreter shared by all
+// calls of Eval, it is not safe for concurrent use. Use Interpreter for
+// independent programs.
+func Eval(s string) (sexpr.Expr, error) {
+	result, _, err := EvalInEnvironment(s, defaultInterpreter.Environment())
+	return result, err
 }
 
-func EvalBuffer(s string, env Environment) (result sexpr.Expr, resultEnv Environment) {
-	// FIXME: is exceptions here good idea? Maybe move to repl or exception free code?
-	defer func() {
-		err := recover()
-		if err != nil {
-			result = fmt.Sprintf("exception: %v", err)
-			// FIXME: good?
-			resultEnv = env
-		}
-	}()
+func EvalInEnvironment(s string, env *Environment) (sexpr.Expr, *Environment, error) {
+	parsed, _, err := sexpr.Read(s)
+	if err != nil {
+		return nil, env, err
+	}
+	result, err := evalToplevel(parsed, env)
+	return result, env, err
+}
 
+func EvalBuffer(s string, env *Environment) (result sexpr.Expr, resultEnv *Environment, err error) {
+	offset := 0
 	for {
-		if strings.TrimSpace(s) == "" {
+		if !sexpr.HasDatum(s[offset:]) {
 			break
 		}
 
-		parsed, remains, ok := sexpr.Parse(s)
-		if !ok {
-			return "parse error", env
+		var parsed sexpr.Expr
+		parsed, offset, err = sexpr.ReadAt(s, offset)
+		if err != nil {
+			return nil, env, err
+		}
+		result, err = evalToplevel(parsed, env)
+		if err != nil {
+			return nil, env, err
 		}
-		result = eval(parsed, env)
-		s = remains
 	}
-	return result, env
+	return result, env, nil
 }
 
-type Lambda struct {
-	Env        Environment
-	Parameters []sexpr.Expr
-	Body       sexpr.Expr
+// evalThen evaluates expression and passes its value to then.
+func evalThen(expr sexpr.Expr, env *Environment, then func(value sexpr.Expr) (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	return nil, &tailCall{Expr: expr, Env: env, Then: then}, nil
 }
 
-func (lambda Lambda) MakeArgEnv(arguments []sexpr.Expr) Environment {
-	env := EmptyEnvironment()
-	for i, value := range arguments {
-		// FIXME: parameters out of index
-		parameter, ok := lambda.Parameters[i].(sexpr.Symbol)
-		if !ok {
-			panic(fmt.Sprintf("invalid parameter %v", lambda.Parameters[i]))
+// evalEach evaluates expressions from left to right and passes their values
+// to then. The values of first expressions are already evaluated, the slice
+// is owned by the call.
+func evalEach(exprs []sexpr.Expr, evaluated []sexpr.Expr, env *Environment, then func(values []sexpr.Expr) (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	for len(evaluated) < len(exprs) {
+		expr := exprs[len(evaluated)]
+		if _, ok := expr.(*sexpr.Pair); ok {
+			return evalThen(expr, env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+				// copy, so the frame may be resumed again
+				next := make([]sexpr.Expr, len(evaluated)+1, len(exprs))
+				copy(next, evaluated)
+				next[len(evaluated)] = value
+				return evalEach(exprs, next, env, then)
+			})
+		}
+		value, err := evalAtom(expr, env)
+		if err != nil {
+			return nil, nil, err
 		}
-		env.Local[parameter] = value
+		evaluated = append(evaluated, value)
 	}
-	return env
-}
-
-func applyLambda(lambda Lambda, arguments []sexpr.Expr) sexpr.Expr {
-	closureEnv := lambda.Env.Extend(lambda.MakeArgEnv(arguments))
-	return eval(lambda.Body, closureEnv)
+	return then(evaluated)
 }
 
-func evalArguments(args []sexpr.Expr, env Environment) []sexpr.Expr {
-	evaledArgs := make([]sexpr.Expr, len(args))
-	for i, arg := range args {
-		evaledArgs[i] = eval(arg, env)
+// evalList evaluates list form. Either result or tail call is returned.
+func evalList(form *sexpr.Pair, env *Environment) (sexpr.Expr, *tailCall, error) {
+	length, ok := sexpr.Length(form)
+	if !ok {
+		err := newError(KindSyntax, "Combination must be a proper list:", form)
+		err.Expr = form
+		return nil, nil, err
 	}
-	return evaledArgs
-}
-
-func evalList(list []sexpr.Expr, env Environment) sexpr.Expr {
-	if len(list) == 0 {
-		return sexpr.List()
+	head := form.Car
+	keyword := syntaxKeyword(head, env)
+	if keyword == "" {
+		return evalOperator(form, length-1, env)
 	}
-	head := list[0]
 
-	switch head {
+	list, _ := sexpr.ToSlice(form)
+	switch keyword {
 	case sexpr.Symbol("quote"):
-		return list[1]
-	case sexpr.Symbol("="):
-		value1 := eval(list[1], env)
-		value2 := eval(list[2], env)
-		return sexpr.Equal(value1, value2)
-	case sexpr.Symbol("null?"):
-		arg := eval(list[1], env)
-		if l, ok := arg.([]sexpr.Expr); ok {
-			return len(l) == 0
-		}
-		return false
+		if len(list) != 2 {
+			return nil, nil, errIllFormed(form)
+		}
+		return stripSyntax(list[1]), nil, nil
+	case sexpr.Symbol("quasiquote"):
+		if len(list) != 2 {
+			return nil, nil, errIllFormed(form)
+		}
+		return evalQuasiquote(list[1], 1, env, returnValue)
+	case sexpr.Symbol("unquote"), sexpr.Symbol("unquote-splicing"):
+		err := newError(KindSyntax, "Unquote outside of quasiquote:", form)
+		err.Expr = form
+		return nil, nil, err
 	case sexpr.Symbol("if"):
-		condition := eval(list[1], env)
-		if condition.(bool) {
-			return eval(list[2], env)
-		} else {
-			return eval(list[3], env)
+		if len(list) != 3 && len(list) != 4 {
+			return nil, nil, errIllFormed(form)
 		}
+		return evalThen(list[1], env, func(condition sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+			if isTrue(condition) {
+				return nil, &tailCall{Expr: list[2], Env: env}, nil
+			}
+			if len(list) == 3 {
+				return nil, nil, nil
+			}
+			return nil, &tailCall{Expr: list[3], Env: env}, nil
+		})
 	case sexpr.Symbol("define"):
-		name := list[1].(sexpr.Symbol)
-		body := list[2]
-		value := eval(body, env)
-		env.Global[name] = value
-		return name
-	case sexpr.Symbol("cons"):
-		car := eval(list[1], env)
-		cdr := eval(list[2], env)
-		// FIXME: optmize and allocate once
-		l := sexpr.List(car)
-		// FIXME: second argument can be pair...
-		// For example, (cons 4 5)  => (4 . 5)
-		l = append(l.([]sexpr.Expr), cdr.([]sexpr.Expr)...)
-		return l
+		return evalDefinition(form, list, env, func(id sexpr.Expr, value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+			name, _ := bindingName(id)
+			env.Define(name, value)
+			return baseName(id), nil, nil
+		})
+	case sexpr.Symbol("set!"):
+		return evalAssignment(form, list, env)
+	case sexpr.Symbol("define-syntax"):
+		name, err := evalDefineSyntax(form, list, env)
+		return name, nil, err
+	case sexpr.Symbol("define-macro"), sexpr.Symbol("defmacro"):
+		return evalDefineMacro(form, list, env)
+	case sexpr.Symbol("let-syntax"):
+		return evalLetSyntax(form, list, env, false)
+	case sexpr.Symbol("letrec-syntax"):
+		return evalLetSyntax(form, list, env, true)
 	case sexpr.Symbol("cond"):
-		result := evalCond(list[1:], env)
-		return result
+		return evalCond(form, list, env)
+	case sexpr.Symbol("case"):
+		return evalCase(form, list, env)
+	case sexpr.Symbol("and"):
+		return evalAnd(list, env)
+	case sexpr.Symbol("or"):
+		return evalOr(list, env)
+	case sexpr.Symbol("when"):
+		return evalWhen(form, list, env, true)
+	case sexpr.Symbol("unless"):
+		return evalWhen(form, list, env, false)
+	case sexpr.Symbol("begin"):
+		return evalSequence(list[1:], env)
+	case sexpr.Symbol("do"):
+		return evalDo(form, list, env)
+	case sexpr.Symbol("guard"):
+		return evalGuard(form, list, env)
+	case sexpr.Symbol("let"):
+		return evalLet(form, list, env)
+	case sexpr.Symbol("let*"):
+		return evalLetStar(form, list, env)
+	case sexpr.Symbol("letrec"):
+		return evalLetrec(form, list, env, false)
+	case sexpr.Symbol("letrec*"):
+		return evalLetrec(form, list, env, true)
+	case sexpr.Symbol("let-values"):
+		return evalLetValues(form, list, env)
 	case sexpr.Symbol("lambda"):
-		return Lambda{
-			Env:        env.Copy(),
-			Parameters: list[1].([]sexpr.Expr),
-			Body:       list[2],
+		if len(list) < 3 {
+			return nil, nil, errIllFormed(form)
+		}
+		lambda, err := makeLambda(list[1], list[2:], env)
+		if err != nil {
+			return nil, nil, withExpr(err, form)
+		}
+		return lambda, nil, nil
+	}
+
+	return evalOperator(form, length-1, env)
+}
+
+// evalOperator evaluates operator of combination form with count operands.
+// Combinations are the most frequent forms, so their operands are walked as
+// pairs without converting form to slice.
+func evalOperator(form *sexpr.Pair, count int, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if isIdentifier(form.Car) {
+		procedure, err := lookupVariable(form.Car, env)
+		if err != nil {
+			return nil, nil, err
 		}
+		return evalCombination(form, count, procedure, env)
 	}
+	return evalThen(form.Car, env, func(procedure sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return evalCombination(form, count, procedure, env)
+	})
+}
 
-	head = eval(head, env)
-	switch head.(type) {
-	case Builtin:
-		return head.(Builtin)(list[1:], env)
-	case Lambda:
-		arguments := evalArguments(list[1:], env)
-		result := applyLambda(head.(Lambda), arguments)
-		return result
-	default:
-		panic(fmt.Sprintf("The object %v is not applicable.", sexpr.Print(head)))
+// evalCombination applies procedure, which is the value of the operator of
+// form, to count operands or expands macro use.
+func evalCombination(form *sexpr.Pair, count int, procedure sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	switch procedure := procedure.(type) {
+	case *Macro:
+		expansion, err := procedure.Transformer(form, env)
+		if err != nil {
+			return nil, nil, withExpr(err, form)
+		}
+		return nil, &tailCall{Expr: expansion, Env: env}, nil
+	}
+	if !isProcedure(procedure) {
+		return nil, nil, withExpr(errNotApplicable(procedure), form)
 	}
+	return evalOperands(form.Cdr, make([]sexpr.Expr, 0, count), env, func(arguments []sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		result, tail, err := apply(procedure, arguments, env)
+		return result, tail, withExpr(err, form)
+	})
 }
 
-func evalCond(conditions []sexpr.Expr, env Environment) sexpr.Expr {
-	for _, clause := range conditions {
-		if clause.([]sexpr.Expr)[0] == sexpr.Symbol("else") {
-			return eval(clause.([]sexpr.Expr)[1], env)
+// evalOperands is evalEach for the proper list of operands.
+func evalOperands(operands sexpr.Expr, evaluated []sexpr.Expr, env *Environment, then func(values []sexpr.Expr) (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	for operands != sexpr.Nil {
+		pair := operands.(*sexpr.Pair)
+		operands = pair.Cdr
+		if _, ok := pair.Car.(*sexpr.Pair); ok {
+			rest := operands
+			return evalThen(pair.Car, env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+				// copy, so the frame may be resumed again
+				next := make([]sexpr.Expr, len(evaluated)+1, cap(evaluated))
+				copy(next, evaluated)
+				next[len(evaluated)] = value
+				return evalOperands(rest, next, env, then)
+			})
 		}
+		value, err := evalAtom(pair.Car, env)
+		if err != nil {
+			return nil, nil, err
+		}
+		evaluated = append(evaluated, value)
+	}
+	return then(evaluated)
+}
+
+// isProcedure reports whether value can be applied to arguments.
+func isProcedure(value sexpr.Expr) bool {
+	switch value.(type) {
+	case *Lambda, *Primitive, *Continuation, controlProcedure:
+		return true
+	}
+	return false
+}
 
-		match := eval(clause.([]sexpr.Expr)[0], env)
-		if match == true {
-			return eval(clause.([]sexpr.Expr)[1], env)
+// apply applies procedure to evaluated arguments. Body of lambda and
+// procedures which control evaluation are returned as tail call.
+func apply(procedure sexpr.Expr, arguments []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	switch procedure := procedure.(type) {
+	case *Lambda:
+		return applyLambda(procedure, arguments)
+	case *Primitive:
+		if procedure.control != nil {
+			return nil, &tailCall{Procedure: procedure.control, Arguments: arguments, Env: env}, nil
+		}
+		result, err := procedure.Procedure(arguments, env)
+		return result, nil, err
+	case *Continuation:
+		var value sexpr.Expr = Values(arguments)
+		if len(arguments) == 1 {
+			value = arguments[0]
 		}
+		return nil, nil, &continuationJump{target: procedure, value: value}
+	case controlProcedure:
+		return nil, &tailCall{Procedure: procedure, Arguments: arguments, Env: env}, nil
 	}
-	panic("no match in cond")
+	return nil, nil, errNotApplicable(procedure)
 }
 
-type Environment struct {
-	Global map[sexpr.Symbol]sexpr.Expr
-	Local  map[sexpr.Symbol]sexpr.Expr
+// applyProcedure calls procedure with already evaluated arguments, it is used
+// by builtins which call procedures. Continuations captured during the call
+// can not be resumed after it returns.
+func applyProcedure(procedure sexpr.Expr, arguments []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	return runNested(&tailCall{Procedure: procedure, Arguments: arguments, Env: env})
 }
 
-func EmptyEnvironment() Environment {
-	return Environment{
-		Global: make(map[sexpr.Symbol]sexpr.Expr),
-		Local:  make(map[sexpr.Symbol]sexpr.Expr),
+// evalSequence evaluates expressions in order, the last one is returned as
+// tail call.
+func evalSequence(body []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	switch len(body) {
+	case 0:
+		return nil, nil, nil
+	case 1:
+		return nil, &tailCall{Expr: body[0], Env: env}, nil
 	}
+	return evalThen(body[0], env, func(sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return evalSequence(body[1:], env)
+	})
 }
 
-func (env Environment) Copy() Environment {
-	copiedLocal := make(map[sexpr.Symbol]sexpr.Expr, len(env.Local))
-	for k, v := range env.Local {
-		copiedLocal[k] = v
+// evalDefinition evaluates value of (define name value) or of the procedure
+// shorthand (define (name . formals) body...) and passes it with the defined
+// identifier to then.
+func evalDefinition(form sexpr.Expr, list []sexpr.Expr, env *Environment, then func(id sexpr.Expr, value sexpr.Expr) (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
 	}
-	return Environment{
-		Global: env.Global,
-		Local:  copiedLocal,
+	if target, ok := list[1].(*sexpr.Pair); ok {
+		if !isIdentifier(target.Car) {
+			return nil, nil, errIllFormed(form)
+		}
+		lambda, err := makeLambda(target.Cdr, list[2:], env)
+		if err != nil {
+			return nil, nil, withExpr(err, form)
+		}
+		lambda.Name = baseName(target.Car)
+		return then(target.Car, lambda)
+	}
+
+	if !isIdentifier(list[1]) || len(list) != 3 {
+		return nil, nil, errIllFormed(form)
 	}
+	return evalThen(list[2], env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		if lambda, ok := value.(*Lambda); ok && lambda.Name == "" {
+			lambda.Name = baseName(list[1])
+		}
+		return then(list[1], value)
+	})
 }
 
-func (env Environment) Extend(extension Environment) Environment {
-	newEnv := env.Copy()
-	// add extension to new environment
-	for k, v := range extension.Local {
-		newEnv.Local[k] = v
+// evalAssignment evaluates (set! name value), the variable must be bound.
+func evalAssignment(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(list) != 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	name, ok := bindingName(list[1])
+	if !ok {
+		return nil, nil, withExpr(errWrongType(list[1], 0, "set!"), form)
 	}
-	return newEnv
+	return evalThen(list[2], env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		// assign in the defining frame
+		if !assignIdentifier(list[1], value, env) {
+			return nil, nil, withExpr(errUnboundVariable(name), form)
+		}
+		return nil, nil, nil
+	})
 }
 
-func DefaultEnvironment() Environment {
-	env := EmptyEnvironment()
-	addBultin(env)
-	return env
+// isTrue reports whether value counts as true in conditionals, every value
+// except #f does.
+func isTrue(value sexpr.Expr) bool {
+	return value != false
 }
 
-func eval(expr sexpr.Expr, env Environment) sexpr.Expr {
+func evalAtom(expr sexpr.Expr, env *Environment) (sexpr.Expr, error) {
 	switch value := expr.(type) {
-	case int:
-		return value
-	case string:
-		return value
+	case int, *big.Int, *big.Rat, float64:
+		return value, nil
+	case string, sexpr.Char, *sexpr.Vector, *sexpr.Bytevector:
+		return value, nil
 	case bool:
-		return value
-	case sexpr.Symbol:
-		v := env.Local[value]
-		if v != nil {
-			return v
+		return value, nil
+	case sexpr.EmptyList:
+		return value, nil
+	case sexpr.Symbol, *Alias:
+		v, err := lookupVariable(value, env)
+		if err != nil {
+			return nil, err
 		}
-
-		v = env.Global[value]
-		if v != nil {
-			return v
+		if _, ok := v.(*Macro); ok {
+			return nil, newError(KindSyntax, "Syntactic keyword may not be used as an expression:", value)
 		}
-		panic("Unbound variable: " + value)
-	case []sexpr.Expr:
-		return evalList(value, env)
+		return v, nil
 	}
-	return nil
+	return nil, nil
 }
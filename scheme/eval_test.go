@@ -1,6 +1,7 @@
 package scheme
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,108 +11,191 @@ import (
 
 func TestEval(t *testing.T) {
 	t.Run("eval int value", func(t *testing.T) {
-		assert.Equal(t, 42, Eval(`42`))
-		assert.Equal(t, -13, Eval(`-13`))
+		assert.Equal(t, 42, mustEval(t, `42`))
+		assert.Equal(t, -13, mustEval(t, `-13`))
 	})
 
 	t.Run("eval int builtin function", func(t *testing.T) {
-		assert.Equal(t, 42, Eval(`(+ 20 22)`))
-		assert.Equal(t, 30, Eval(`(* 5 6)`))
-		assert.Equal(t, 10, Eval(`(- 20 10)`))
+		assert.Equal(t, 42, mustEval(t, `(+ 20 22)`))
+		assert.Equal(t, 30, mustEval(t, `(* 5 6)`))
+		assert.Equal(t, 10, mustEval(t, `(- 20 10)`))
 
 		t.Run("multiple arguments", func(t *testing.T) {
-			t.Skip("currently multiple arguments of + is not supported")
-			assert.Equal(t, 52, Eval(`(+ 20 22 10)`))
+			assert.Equal(t, 52, mustEval(t, `(+ 20 22 10)`))
 		})
 
 		t.Run("division", func(t *testing.T) {
-			t.Skip("unsupported")
-			assert.Equal(t, 5, Eval(`(/ 10 2)`))
+			assert.Equal(t, 5, mustEval(t, `(/ 10 2)`))
 		})
 	})
 
 	t.Run("complex plus", func(t *testing.T) {
-		assert.Equal(t, 42, Eval(`(+ (* 10 2) (+ 2 20))`))
+		assert.Equal(t, 42, mustEval(t, `(+ (* 10 2) (+ 2 20))`))
 	})
 
 	t.Run("boolean", func(t *testing.T) {
-		assert.Equal(t, true, Eval(`(= 3 3)`))
-		assert.Equal(t, false, Eval(`(= 3 4)`))
-		assert.Equal(t, false, Eval(`(= #t #f)`))
-		assert.Equal(t, true, Eval(`(= #t #t)`))
-		assert.Equal(t, true, Eval(`(= #f #f)`))
+		assert.Equal(t, true, mustEval(t, `(= 3 3)`))
+		assert.Equal(t, false, mustEval(t, `(= 3 4)`))
+		assert.Equal(t, false, mustEval(t, `(= #t #f)`))
+		assert.Equal(t, true, mustEval(t, `(= #t #t)`))
+		assert.Equal(t, true, mustEval(t, `(= #f #f)`))
 	})
 
 	t.Run("equal", func(t *testing.T) {
-		assert.Equal(t, false, Eval(`(= 1 "1")`))
-		assert.Equal(t, false, Eval(`(= 'foo "foo")`))
-		assert.Equal(t, true, Eval(`(= () ())`))
-		assert.Equal(t, true, Eval(`(= '(3 4 5) '(3 4 5))`))
-		assert.Equal(t, false, Eval(`(= '(3 4 5) '(3 4 5 6))`))
-		assert.Equal(t, false, Eval(`(= () '(3))`))
-		assert.Equal(t, false, Eval(`(= '(3) ())`))
+		assert.Equal(t, false, mustEval(t, `(= 1 "1")`))
+		assert.Equal(t, false, mustEval(t, `(= 'foo "foo")`))
+		assert.Equal(t, true, mustEval(t, `(= () ())`))
+		assert.Equal(t, true, mustEval(t, `(= '(3 4 5) '(3 4 5))`))
+		assert.Equal(t, false, mustEval(t, `(= '(3 4 5) '(3 4 5 6))`))
+		assert.Equal(t, false, mustEval(t, `(= () '(3))`))
+		assert.Equal(t, false, mustEval(t, `(= '(3) ())`))
 	})
 
 	t.Run("if", func(t *testing.T) {
-		assert.Equal(t, 5, Eval(`(if (= 3 3) 5 0)`))
-		assert.Equal(t, 0, Eval(`(if (= 3 4) 5 0)`))
+		assert.Equal(t, 5, mustEval(t, `(if (= 3 3) 5 0)`))
+		assert.Equal(t, 0, mustEval(t, `(if (= 3 4) 5 0)`))
+	})
+
+	t.Run("every value except #f is true", func(t *testing.T) {
+		assert.Equal(t, "yes", mustEval(t, `(if 0 "yes" "no")`))
+		assert.Equal(t, "yes", mustEval(t, `(if '() "yes" "no")`))
+		assert.Equal(t, "yes", mustEval(t, `(if "" "yes" "no")`))
+		assert.Equal(t, "no", mustEval(t, `(if #f "yes" "no")`))
+		assert.Equal(t, 2, mustEval(t, `(if (assq 'b '((a 1) (b 2))) 2 0)`))
+		assert.Equal(t, 0, mustEval(t, `(if (assq 'c '((a 1) (b 2))) 2 0)`))
+		assert.Equal(t, 16, mustEval(t, `(cond (#f 5) ('(1) 16) (else 88))`))
+		assert.Equal(t, 88, mustEval(t, `(cond (#f 5) (else 88))`))
+	})
+
+	t.Run("not and boolean?", func(t *testing.T) {
+		assert.Equal(t, true, mustEval(t, `(not #f)`))
+		assert.Equal(t, false, mustEval(t, `(not #t)`))
+		assert.Equal(t, false, mustEval(t, `(not 0)`))
+		assert.Equal(t, false, mustEval(t, `(not '())`))
+		assert.Equal(t, true, mustEval(t, `(boolean? #f)`))
+		assert.Equal(t, false, mustEval(t, `(boolean? '())`))
+		assert.Equal(t, KindArity, evalError(t, `(not)`).Kind)
 	})
 
 	t.Run("cond", func(t *testing.T) {
-		assert.Equal(t, true, Eval(`(= 5 (cond ((= 3 3) 5) (else 88)))`))
-		assert.Equal(t, true, Eval(`(= 88 (cond ((= 3 6) 5) (else 88)))`))
-		assert.Equal(t, true, Eval(`(= 88 (cond ((= 3 4) 5) (#f 16) (#t 88)))`))
+		assert.Equal(t, true, mustEval(t, `(= 5 (cond ((= 3 3) 5) (else 88)))`))
+		assert.Equal(t, true, mustEval(t, `(= 88 (cond ((= 3 6) 5) (else 88)))`))
+		assert.Equal(t, true, mustEval(t, `(= 88 (cond ((= 3 4) 5) (#f 16) (#t 88)))`))
 	})
 
 	t.Run("null?", func(t *testing.T) {
-		assert.Equal(t, true, Eval(`(null? ())`))
-		assert.Equal(t, false, Eval(`(null? (quote (1)))`))
-		assert.Equal(t, false, Eval(`(null? 5)`))
+		assert.Equal(t, true, mustEval(t, `(null? ())`))
+		assert.Equal(t, false, mustEval(t, `(null? (quote (1)))`))
+		assert.Equal(t, false, mustEval(t, `(null? 5)`))
 	})
 
 	t.Run("car cdr cons", func(t *testing.T) {
-		assert.Equal(t, 4, Eval(`(car (quote (4 5 6)))`))
+		assert.Equal(t, 4, mustEval(t, `(car (quote (4 5 6)))`))
 		assert.Equal(t,
 			sexpr.List(2, 3),
-			Eval(`(cdr (quote (1 2 3)))`),
+			mustEval(t, `(cdr (quote (1 2 3)))`),
 		)
-		assert.Equal(t, true, Eval(`(= 1 (car (cons 1 '(2 3))))`))
-		assert.Equal(t, true, Eval(`(= 2 (car (cdr (cons 1 '(2 3)))))`))
+		assert.Equal(t, true, mustEval(t, `(= 1 (car (cons 1 '(2 3))))`))
+		assert.Equal(t, true, mustEval(t, `(= 2 (car (cdr (cons 1 '(2 3)))))`))
+	})
+
+	t.Run("dotted pairs", func(t *testing.T) {
+		assert.Equal(t, sexpr.Cons(4, 5), mustEval(t, `(cons 4 5)`))
+		assert.Equal(t, 5, mustEval(t, `(cdr (cons 4 5))`))
+		assert.Equal(t, sexpr.Cons(1, sexpr.Cons(2, 3)), mustEval(t, `'(1 2 . 3)`))
+		assert.Equal(t, sexpr.List(1, 2), mustEval(t, `(cons 1 (cons 2 '()))`))
+		assert.Equal(t, "(1 2 . 3)", sexpr.Print(mustEval(t, `(cons 1 (cons 2 3))`)))
+	})
+
+	t.Run("set-car! set-cdr!", func(t *testing.T) {
+		mustEval(t, `(define p (list 1 2 3))`)
+		mustEval(t, `(set-car! p 'one)`)
+		mustEval(t, `(set-cdr! (cdr p) 'end)`)
+		assert.Equal(t, "(one 2 . end)", sexpr.Print(mustEval(t, `p`)))
+		assert.Equal(t, KindWrongType, evalError(t, `(set-car! '() 1)`).Kind)
+	})
+
+	t.Run("circular lists", func(t *testing.T) {
+		mustEval(t, `(define ring (list 1 2))`)
+		mustEval(t, `(set-cdr! (cdr ring) ring)`)
+		mustEval(t, `(define other-ring (list 1 2))`)
+		mustEval(t, `(set-cdr! (cdr other-ring) other-ring)`)
+		assert.Equal(t, "#0=(1 2 . #0#)", sexpr.Print(mustEval(t, `ring`)))
+		assert.Equal(t, true, mustEval(t, `(equal? ring other-ring)`))
+	})
+
+	t.Run("shared structure", func(t *testing.T) {
+		mustEval(t, `(define tail (list 2 3))`)
+		mustEval(t, `(define one (cons 1 tail))`)
+		mustEval(t, `(define other (cons 0 tail))`)
+		mustEval(t, `(set-car! tail 'two)`)
+		assert.Equal(t, sexpr.List(1, sexpr.Symbol("two"), 3), mustEval(t, `one`))
+		assert.Equal(t, sexpr.List(0, sexpr.Symbol("two"), 3), mustEval(t, `other`))
+		assert.Equal(t, true, mustEval(t, `(eq? (cdr one) (cdr other))`))
+		assert.Equal(t, false, mustEval(t, `(eq? (list 1) (list 1))`))
+		assert.Equal(t, true, mustEval(t, `(equal? (list 1) (list 1))`))
+	})
+
+	t.Run("procedures are the same only to themselves", func(t *testing.T) {
+		assert.Equal(t, true, mustEval(t, `(eq? car car)`))
+		assert.Equal(t, false, mustEval(t, `(eq? car cdr)`))
+		assert.Equal(t, true, mustEval(t, `(eqv? call/cc call/cc)`))
+		assert.Equal(t, true, mustEval(t, `(let ((f (lambda () 1))) (eqv? f f))`))
+		assert.Equal(t, false, mustEval(t, `(eqv? (lambda () 1) (lambda () 1))`))
+		assert.Equal(t, true, mustEval(t, `(equal? (list car) (list car))`))
+		assert.Equal(t, true, mustEval(t, `(let ((f (lambda () 1))) (equal? f f))`))
+		assert.Equal(t, false, mustEval(t, `(equal? car cdr)`))
+	})
+
+	t.Run("association lists", func(t *testing.T) {
+		mustEval(t, `(define colors '((red . 1) (green . 2) ((blue) . 3)))`)
+		assert.Equal(t, sexpr.Cons(sexpr.Symbol("green"), 2), mustEval(t, `(assq 'green colors)`))
+		assert.Equal(t, false, mustEval(t, `(assq 'black colors)`))
+		assert.Equal(t, false, mustEval(t, `(assq '(blue) colors)`))
+		assert.Equal(t, 3, mustEval(t, `(cdr (assoc '(blue) colors))`))
+		assert.Equal(t, KindWrongType, evalError(t, `(assq 'red '(1 2))`).Kind)
+	})
+
+	t.Run("pair?", func(t *testing.T) {
+		assert.Equal(t, true, mustEval(t, `(pair? (cons 1 2))`))
+		assert.Equal(t, true, mustEval(t, `(pair? '(1))`))
+		assert.Equal(t, false, mustEval(t, `(pair? '())`))
+		assert.Equal(t, false, mustEval(t, `(list? (cons 1 2))`))
 	})
 
 	t.Run("list?", func(t *testing.T) {
-		assert.Equal(t, true, Eval(`(= #t (list? '(4 5 6)))`))
-		assert.Equal(t, true, Eval(`(= #t (list? ()))`))
-		assert.Equal(t, true, Eval(`(= #f (list? 5))`))
-		assert.Equal(t, true, Eval(`(= #f (list? 'foo))`))
-		assert.Equal(t, true, Eval(`(= #f (list? "bar"))`))
+		assert.Equal(t, true, mustEval(t, `(= #t (list? '(4 5 6)))`))
+		assert.Equal(t, true, mustEval(t, `(= #t (list? ()))`))
+		assert.Equal(t, true, mustEval(t, `(= #f (list? 5))`))
+		assert.Equal(t, true, mustEval(t, `(= #f (list? 'foo))`))
+		assert.Equal(t, true, mustEval(t, `(= #f (list? "bar"))`))
 	})
 
 	t.Run("symbol?", func(t *testing.T) {
-		assert.Equal(t, true, Eval(`(= #f (symbol? '(4 5 6)))`))
-		assert.Equal(t, true, Eval(`(= #f (symbol? ()))`))
-		assert.Equal(t, true, Eval(`(= #f (symbol? 5))`))
-		assert.Equal(t, true, Eval(`(= #t (symbol? 'foo))`))
-		assert.Equal(t, true, Eval(`(= #t (symbol? (quote foo)))`))
-		assert.Equal(t, true, Eval(`(= #f (symbol? "bar"))`))
-		assert.Equal(t, true, Eval(`(= #f (symbol? (quote (a b))))`))
+		assert.Equal(t, true, mustEval(t, `(= #f (symbol? '(4 5 6)))`))
+		assert.Equal(t, true, mustEval(t, `(= #f (symbol? ()))`))
+		assert.Equal(t, true, mustEval(t, `(= #f (symbol? 5))`))
+		assert.Equal(t, true, mustEval(t, `(= #t (symbol? 'foo))`))
+		assert.Equal(t, true, mustEval(t, `(= #t (symbol? (quote foo)))`))
+		assert.Equal(t, true, mustEval(t, `(= #f (symbol? "bar"))`))
+		assert.Equal(t, true, mustEval(t, `(= #f (symbol? (quote (a b))))`))
 	})
 
 	t.Run("quotes", func(t *testing.T) {
 		assert.Equal(
 			t,
 			sexpr.List(1, 2, 3),
-			Eval(`(quote (1 2 3))`),
+			mustEval(t, `(quote (1 2 3))`),
 		)
 		assert.Equal(
 			t,
 			5,
-			Eval(`(quote 5)`),
+			mustEval(t, `(quote 5)`),
 		)
 		assert.Equal(
 			t,
 			true,
-			Eval(`
+			mustEval(t, `
 				(=
 					'(+ '(4 5 6))
 					(quote (+ (quote (4 5 6)))))
@@ -120,29 +204,133 @@ func TestEval(t *testing.T) {
 
 	t.Run("with variables", func(t *testing.T) {
 		// arrange
-		Eval(`(define forty-two (* 21 2))`)
+		mustEval(t, `(define forty-two (* 21 2))`)
 
 		// act
-		result := Eval(`(+ forty-two 1)`)
+		result := mustEval(t, `(+ forty-two 1)`)
 
 		// assert
 		assert.Equal(t, 43, result)
 	})
 
 	t.Run("simple lambda", func(t *testing.T) {
-		assert.Equal(t, 25, Eval(`((lambda (x) (* x x)) 5)`))
+		assert.Equal(t, 25, mustEval(t, `((lambda (x) (* x x)) 5)`))
 	})
 
 	t.Run("several arguments lambda", func(t *testing.T) {
-		assert.Equal(t, 33, Eval(`((lambda (x y z)  (+ z (* x y))) 5 6 3)`))
+		assert.Equal(t, 33, mustEval(t, `((lambda (x y z)  (+ z (* x y))) 5 6 3)`))
+	})
+
+	t.Run("lambda arity", func(t *testing.T) {
+		mustEval(t, `(define add2 (lambda (a b) (+ a b)))`)
+
+		err := evalError(t, `(add2 1)`)
+		assert.Equal(t, KindArity, err.Kind)
+		assert.Equal(
+			t,
+			"Wrong number of arguments passed to #[compound-procedure add2], expected 2, got: 1",
+			err.Error(),
+		)
+		assert.Equal(t, KindArity, evalError(t, `(add2 1 2 3)`).Kind)
+		assert.Equal(t, KindArity, evalError(t, `((lambda () 1) 1)`).Kind)
+		assert.Equal(t, 1, mustEval(t, `((lambda () 1))`))
+	})
+
+	t.Run("rest parameters", func(t *testing.T) {
+		assert.Equal(t, sexpr.List(1, 2, 3), mustEval(t, `((lambda args args) 1 2 3)`))
+		assert.Equal(t, sexpr.List(), mustEval(t, `((lambda args args))`))
+		assert.Equal(t,
+			sexpr.List(1, 2, sexpr.List(3, 4)),
+			mustEval(t, `((lambda (a b . rest) (list a b rest)) 1 2 3 4)`),
+		)
+		assert.Equal(t,
+			sexpr.List(1, 2, sexpr.List()),
+			mustEval(t, `((lambda (a b . rest) (list a b rest)) 1 2)`),
+		)
+		assert.Equal(t, KindArity, evalError(t, `((lambda (a b . rest) a) 1)`).Kind)
+		assert.Equal(t,
+			sexpr.List(1, sexpr.List(2)),
+			mustEval(t, `((lambda (a #!rest r) (list a r)) 1 2)`),
+		)
+	})
+
+	t.Run("optional parameters", func(t *testing.T) {
+		mustEval(t, `
+			(define opt
+				(lambda (a #!optional b (c (* a 10)))
+					(list a (if (default-object? b) 'none b) c)))
+		`)
+		assert.Equal(t, sexpr.List(1, sexpr.Symbol("none"), 10), mustEval(t, `(opt 1)`))
+		assert.Equal(t, sexpr.List(1, 2, 10), mustEval(t, `(opt 1 2)`))
+		assert.Equal(t, sexpr.List(1, 2, 3), mustEval(t, `(opt 1 2 3)`))
+
+		err := evalError(t, `(opt 1 2 3 4)`)
+		assert.Equal(t, KindArity, err.Kind)
+		assert.Contains(t, err.Error(), "expected 1 to 3, got: 4")
+		assert.Equal(t, KindArity, evalError(t, `(opt)`).Kind)
+	})
+
+	t.Run("continuation of optional default is reentered", func(t *testing.T) {
+		assert.Equal(t, "((1 3) 3)", sexpr.Print(mustEval(t, `
+			(let ((k #f) (n 0))
+				(let ((result ((lambda (a #!optional (b (call/cc (lambda (c) (set! k c) 1)))) (list a b)) 1)))
+					(set! n (+ n 1))
+					(if (< n 3) (k (+ n 1)) (list result n))))`)))
+	})
+
+	t.Run("keyword parameters", func(t *testing.T) {
+		mustEval(t, `
+			(define rect
+				(lambda (name #!key (width 1) (height width))
+					(list name width height)))
+		`)
+		assert.Equal(t, sexpr.List(sexpr.Symbol("a"), 1, 1), mustEval(t, `(rect 'a)`))
+		assert.Equal(t, sexpr.List(sexpr.Symbol("a"), 2, 5), mustEval(t, `(rect 'a height: 5 width: 2)`))
+		assert.Equal(t, sexpr.List(sexpr.Symbol("a"), 3, 3), mustEval(t, `(rect 'a width: 3)`))
+		assert.Equal(t, KindBadRange, evalError(t, `(rect 'a depth: 3)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(rect 'a width:)`).Kind)
+		assert.Equal(t, KindWrongType, evalError(t, `(rect 'a 1 2)`).Kind)
+	})
+
+	t.Run("variables shadow special forms", func(t *testing.T) {
+		assert.Equal(t, -5, mustEval(t, `(let ((quote -)) (quote 5))`))
+		assert.Equal(t, 2, mustEval(t, `(let ((else #f)) (cond (else 1) (#t 2)))`))
+
+		// act
+		result, _, err := EvalBuffer(`
+			(define (if x) x)
+			(if 1)`,
+			DefaultEnvironment())
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result)
+	})
+
+	t.Run("bound keyword evaluates to its value", func(t *testing.T) {
+		// arrange
+		mustEval(t, `(define bound-keyword: 1)`)
+
+		// assert
+		assert.Equal(t, 1, mustEval(t, `bound-keyword:`))
+		assert.Equal(t, sexpr.Symbol("unbound-keyword:"), mustEval(t, `unbound-keyword:`))
+	})
+
+	t.Run("invalid parameter lists", func(t *testing.T) {
+		assert.Equal(t, KindSyntax, evalError(t, `(lambda (a a) a)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(lambda (a 1) a)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(lambda (a #!rest) a)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(lambda (#!rest a b) a)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(lambda (#!rest a . b) a)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(lambda (#!key a #!optional b) a)`).Kind)
 	})
 
 	t.Run("define lambda", func(t *testing.T) {
 		// arrange
-		Eval(`(define square (lambda (x) (* x x)))`)
+		mustEval(t, `(define square (lambda (x) (* x x)))`)
 
 		// act
-		result := Eval(`(square 6)`)
+		result := mustEval(t, `(square 6)`)
 
 		// assert
 		assert.Equal(t, 36, result)
@@ -150,10 +338,10 @@ func TestEval(t *testing.T) {
 
 	t.Run("should evaluate arguments of lambda", func(t *testing.T) {
 		// arrange
-		Eval(`(define square (lambda (x) (* x x)))`)
+		mustEval(t, `(define square (lambda (x) (* x x)))`)
 
 		// act
-		result := Eval(`(square (+ 3 3))`)
+		result := mustEval(t, `(square (+ 3 3))`)
 
 		// assert
 		assert.Equal(t, 36, result)
@@ -161,11 +349,11 @@ func TestEval(t *testing.T) {
 
 	t.Run("pass lambda in lambda lambda", func(t *testing.T) {
 		// arrange
-		Eval(`(define double (lambda (x) (+ x x)))`)
-		Eval(`(define twice  (lambda (fn) (lambda (x) (fn (fn x)))))`)
+		mustEval(t, `(define double (lambda (x) (+ x x)))`)
+		mustEval(t, `(define twice  (lambda (fn) (lambda (x) (fn (fn x)))))`)
 
 		// act
-		result := Eval(`((twice double) 10)`)
+		result := mustEval(t, `((twice double) 10)`)
 
 		// assert
 		assert.Equal(t, 40, result)
@@ -173,19 +361,20 @@ func TestEval(t *testing.T) {
 
 	t.Run("apply should not extend scope", func(t *testing.T) {
 		// arrange
-		Eval(`(define hack   (lambda (x) (+ x secret)))`)
-		Eval(`(define safe   (lambda (secret) (hack 23)))`)
+		mustEval(t, `(define hack   (lambda (x) (+ x secret)))`)
+		mustEval(t, `(define safe   (lambda (secret) (hack 23)))`)
 
 		// act
-		result := Eval(`(safe 42)`)
+		err := evalError(t, `(safe 42)`)
 
 		// assert
-		assert.Contains(t, result, "exception: Unbound variable: secret")
+		assert.Equal(t, KindUnboundVariable, err.Kind)
+		assert.Equal(t, "Unbound variable: secret", err.Error())
 	})
 
 	t.Run("original lambda environment should remain after calling function", func(t *testing.T) {
 		// act
-		result := Eval(`
+		result := mustEval(t, `
        ((lambda (y) 
    			(+ ((lambda (x) y) y)
                ((lambda (x) y) y)))
@@ -203,12 +392,12 @@ func TestEval(t *testing.T) {
 						'hi
 						(lambda (x) unbound)))
 		`
-		assert.Equal(t, sexpr.Symbol("hi"), Eval(prog))
+		assert.Equal(t, sexpr.Symbol("hi"), mustEval(t, prog))
 	})
 
 	t.Run("recursion", func(t *testing.T) {
 		// arrange
-		Eval(`
+		mustEval(t, `
 			(define fact
 				(lambda (n)
 					(if (= n 0)
@@ -217,12 +406,102 @@ func TestEval(t *testing.T) {
 		`)
 
 		// assert
-		assert.Equal(t, 120, Eval(`(fact 5)`))
+		assert.Equal(t, 120, mustEval(t, `(fact 5)`))
+	})
+
+	t.Run("lambda body is a sequence", func(t *testing.T) {
+		result := mustEval(t, `
+			((lambda (x)
+				(set! x (+ x 1))
+				(set! x (* x 2))
+				x)
+			 4)`)
+
+		assert.Equal(t, 10, result)
+		assert.Equal(t, KindSyntax, evalError(t, `(lambda (x))`).Kind)
+	})
+
+	t.Run("define procedure shorthand", func(t *testing.T) {
+		// arrange
+		mustEval(t, `(define (add-all first . rest) (if (null? rest) first (+ first (car rest))))`)
+
+		// assert
+		assert.Equal(t, 3, mustEval(t, `(add-all 1 2)`))
+		assert.Equal(t, "#[compound-procedure add-all]", sexpr.Print(mustEval(t, `add-all`)))
+	})
+
+	t.Run("primitive procedures are printed with their names", func(t *testing.T) {
+		assert.Equal(t, "#[procedure car]", sexpr.Print(mustEval(t, `car`)))
+		assert.Equal(t, "#[procedure call/cc]", sexpr.Print(mustEval(t, `call/cc`)))
+		assert.Equal(t, "(#[procedure +])", sexpr.Display(mustEval(t, `(list +)`)))
+	})
+
+	t.Run("internal defines are local to the body", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define (sum-squares a b)
+				(define (square x) (* x x))
+				(define a2 (square a))
+				(+ a2 (square b)))
+		`)
+
+		// act
+		result := mustEval(t, `(sum-squares 3 4)`)
+
+		// assert
+		assert.Equal(t, 25, result)
+		assert.Equal(t, KindUnboundVariable, evalError(t, `a2`).Kind)
+	})
+
+	t.Run("internal defines are mutually recursive", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define (parity n)
+				(define (even? n) (if (= n 0) 'even (odd? (- n 1))))
+				(define (odd? n) (if (= n 0) 'odd (even? (- n 1))))
+				(even? n))
+		`)
+
+		// assert
+		assert.Equal(t, sexpr.Symbol("odd"), mustEval(t, `(parity 7)`))
+		assert.Equal(t, true, mustEval(t, `(even? 2)`))
+	})
+
+	t.Run("tail calls run in constant stack", func(t *testing.T) {
+		// arrange
+		interp := NewInterpreter(WithDepthLimit(100))
+		_, err := interp.EvalString(`
+			(define count-if
+				(lambda (n acc)
+					(if (= n 0)
+						acc
+						(count-if (- n 1) (+ acc 1)))))
+			(define count-cond
+				(lambda (n acc)
+					(cond
+						((= n 0) acc)
+						(else (count-cond (- n 1) (+ acc 1))))))
+			(define count-begin
+				(lambda (n acc)
+					(begin
+						(set! n n)
+						(if (= n 0)
+							acc
+							(count-begin (- n 1) (+ acc 1))))))
+		`)
+		assert.NoError(t, err)
+
+		// assert
+		for _, name := range []string{"if", "cond", "begin"} {
+			result, err := interp.EvalString(`(count-` + name + ` 1000000 0)`)
+			assert.NoError(t, err, name)
+			assert.Equal(t, 1000000, result, name)
+		}
 	})
 
 	// https://www.youtube.com/watch?v=OyfBQmvr2Hc
 	t.Run("most beautiful program ever (meta circular evaluator)", func(t *testing.T) {
-		Eval(`
+		mustEval(t, `
 			(define eval-expr
 				(lambda (expr env)
 					(cond
@@ -246,55 +525,134 @@ func TestEval(t *testing.T) {
 						'hi
 						unbound))))
 		`
-		assert.Equal(t, sexpr.Symbol("hi"), Eval(prog))
+		assert.Equal(t, sexpr.Symbol("hi"), mustEval(t, prog))
 	})
 
 	t.Run("error on unbound variable", func(t *testing.T) {
-		assert.Contains(t, Eval(`foo`), "exception: Unbound variable: foo")
+		err := evalError(t, `foo`)
+		assert.Equal(t, KindUnboundVariable, err.Kind)
+		assert.Equal(t, "Unbound variable: foo", err.Error())
+		assert.Equal(t, []sexpr.Expr{sexpr.Symbol("foo")}, err.Irritants)
 	})
 
 	t.Run("error on applying non-lambda", func(t *testing.T) {
-		assert.Equal(t, `exception: The object "+" is not applicable.`, Eval(`("+" 1 2)`))
+		err := evalError(t, `("+" 1 2)`)
+		assert.Equal(t, KindNotApplicable, err.Kind)
+		assert.Equal(t, `The object is not applicable: "+"`, err.Error())
+		assert.Equal(t, sexpr.List("+", 1, 2), err.Expr)
 	})
 
 	t.Run("error on applying car/cdr to wrong types", func(t *testing.T) {
+		err := evalError(t, `(car 1)`)
+		assert.Equal(t, KindWrongType, err.Kind)
 		assert.Equal(
 			t,
-			`exception: The object 1, passed as the first argument to car, is not the correct type.`,
-			Eval(`(car 1)`),
-		)
-		assert.Equal(
-			t,
-			`exception: The object "foo", passed as the first argument to cdr, is not the correct type.`,
-			Eval(`(cdr "foo")`),
+			`The object, passed as the first argument to car, is not the correct type: 1`,
+			err.Error(),
 		)
+		assert.Equal(t, sexpr.List(sexpr.Symbol("car"), 1), err.Expr)
+
+		err = evalError(t, `(cdr "foo")`)
+		assert.Equal(t, KindWrongType, err.Kind)
+		assert.Equal(t, []sexpr.Expr{"foo"}, err.Irritants)
+	})
+
+	t.Run("error on wrong number of arguments", func(t *testing.T) {
+		err := evalError(t, `(car '(1) '(2))`)
+		assert.Equal(t, KindArity, err.Kind)
+	})
+
+	t.Run("error on ill-formed special form", func(t *testing.T) {
+		assert.Equal(t, KindSyntax, evalError(t, `(quote)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(if)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(define 1 2)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(lambda x)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(+ 1 . 2)`).Kind)
+	})
+
+	t.Run("error is not a string result", func(t *testing.T) {
+		result, err := Eval(`"exception: not really"`)
+		assert.NoError(t, err)
+		assert.Equal(t, "exception: not really", result)
+
+		result, err = Eval(`(car "exception")`)
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		_, err := Eval("(1\n  \"2)")
+		var parseErr *sexpr.ParseError
+		assert.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, "2:3: unterminated string", err.Error())
 	})
 
 	// https://stackoverflow.com/questions/526082/in-scheme-whats-the-point-of-set
 
 	t.Run("redefine global scope with set", func(t *testing.T) {
 		// arrange
-		Eval(`
+		mustEval(t, `
 			(define my-var 21)
 		`)
 		// needed because we check that set modifies
 		// global scope and not local in this case
-		Eval(`
+		mustEval(t, `
 			(define get-my-var (lambda () my-var))
 		`)
 
 		// act
-		Eval(`(set! my-var (* 2 my-var))`)
+		mustEval(t, `(set! my-var (* 2 my-var))`)
+
+		// assert
+		assert.Equal(t, 42, mustEval(t, `(get-my-var)`))
+		assert.Equal(t, 42, mustEval(t, `my-var`))
+	})
+
+	t.Run("closures share mutable state", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define (make-counter)
+				(let ((count 0))
+					(lambda ()
+						(set! count (+ count 1))
+						count)))
+		`)
+		mustEval(t, `(define counter (make-counter))`)
+		mustEval(t, `(define other-counter (make-counter))`)
+
+		// act
+		mustEval(t, `(counter)`)
+		mustEval(t, `(counter)`)
 
 		// assert
-		assert.Equal(t, 42, Eval(`(get-my-var)`))
-		assert.Equal(t, 42, Eval(`my-var`))
+		assert.Equal(t, 3, mustEval(t, `(counter)`))
+		assert.Equal(t, 1, mustEval(t, `(other-counter)`))
+		assert.Equal(t, KindUnboundVariable, evalError(t, `count`).Kind)
+	})
+
+	t.Run("set! assigns to the defining frame", func(t *testing.T) {
+		result := mustEval(t, `
+			(let ((x 1))
+				(let ((get (lambda () x))
+				      (put! (lambda (v) (set! x v))))
+					(put! 42)
+					(list x (get))))`)
+
+		assert.Equal(t, sexpr.List(42, 42), result)
 	})
 
-	t.Run("do notation (progn)", func(t *testing.T) {
+	t.Run("set! of unbound variable", func(t *testing.T) {
+		err := evalError(t, `(let () (set! unassigned 2))`)
+
+		assert.Equal(t, KindUnboundVariable, err.Kind)
+		assert.Equal(t, KindUnboundVariable, evalError(t, `unassigned`).Kind)
+	})
+
+	t.Run("begin", func(t *testing.T) {
 		// act
-		result := Eval(`
-			(do
+		result := mustEval(t, `
+			(begin
+				(define x 0)
 				(set! x 5)
 				(set! x (+ x 1))
 				x)
@@ -307,23 +665,43 @@ func TestEval(t *testing.T) {
 
 func assertEval(t *testing.T, prog string) {
 	t.Helper()
-	if Eval(prog) != true {
+	if mustEval(t, prog) != true {
 		t.Errorf("evaluation should return true")
 	}
 }
 
+func mustEval(t *testing.T, prog string) sexpr.Expr {
+	t.Helper()
+	result, err := Eval(prog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result
+}
+
+func evalError(t *testing.T, prog string) *Error {
+	t.Helper()
+	_, err := Eval(prog)
+	var evalErr *Error
+	if !errors.As(err, &evalErr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	return evalErr
+}
+
 func TestEvalBuffer(t *testing.T) {
 	t.Run("empty", func(t *testing.T) {
 		// act
-		result, _ := EvalBuffer(``, DefaultEnvironment())
+		result, _, err := EvalBuffer(``, DefaultEnvironment())
 
 		// assert
+		assert.NoError(t, err)
 		assert.Nil(t, result)
 	})
 
 	t.Run("success", func(t *testing.T) {
 		// act
-		result, _ := EvalBuffer(`
+		result, _, err := EvalBuffer(`
 			(define x 21)
 			(define y 1)
 			(set! y (+ y 1))
@@ -332,6 +710,49 @@ func TestEvalBuffer(t *testing.T) {
 			DefaultEnvironment())
 
 		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 42, result)
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		// act
+		result, _, err := EvalBuffer(`
+			; a script with comments
+			(define x 21) ; the answer is near
+			#| block
+			   comment |#
+			(* x #;(ignored) 2)
+			; trailing comment
+`,
+			DefaultEnvironment())
+
+		// assert
+		assert.NoError(t, err)
 		assert.Equal(t, 42, result)
 	})
+
+	t.Run("stops at first error", func(t *testing.T) {
+		// act
+		_, env, err := EvalBuffer(`
+			(define x 1)
+			(car x)
+			(define y 2)
+`,
+			DefaultEnvironment())
+
+		// assert
+		assert.Error(t, err)
+		_, defined := env.Lookup("x")
+		assert.True(t, defined)
+		_, defined = env.Lookup("y")
+		assert.False(t, defined)
+	})
+
+	t.Run("parse error position is relative to buffer", func(t *testing.T) {
+		// act
+		_, _, err := EvalBuffer("(define x 1)\n(define y (+ x 1)\n", DefaultEnvironment())
+
+		// assert
+		assert.EqualError(t, err, "3:1: unclosed list opened at 2:1")
+	})
 }
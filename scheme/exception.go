@@ -0,0 +1,228 @@
+package scheme
+
+import (
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// handler is an exception handler installed by with-exception-handler or
+// guard, it is called in the dynamic environment of raise except that the
+// current handlers are the outer ones linked by next.
+type handler struct {
+	procedure sexpr.Expr
+	env       *Environment
+	next      *handler
+}
+
+// noHandlers ends every chain of handlers.
+var noHandlers = &handler{}
+
+// uncaughtException is returned as error when there is no handler for raised
+// object. Machine passes it to its caller without calling handlers again.
+type uncaughtException struct {
+	condition sexpr.Expr
+}
+
+func (e *uncaughtException) Error() string {
+	return raisedError(e).Error()
+}
+
+// guardSignal is passed to continuation of guard when its body raises.
+type guardSignal struct {
+	condition sexpr.Expr
+	// raiseK is continuation of the handler call, the condition is raised
+	// again there when no guard clause is selected.
+	raiseK *Continuation
+	// nested is set when raiseK belongs to nested machine which returned on
+	// leaving the guard body, so it can not be resumed.
+	nested bool
+}
+
+// condition returns the object which is passed to handlers for the error.
+func (e *Error) condition() sexpr.Expr {
+	if e.Raised != nil {
+		return e.Raised
+	}
+	return e
+}
+
+// raisedError converts uncaught exception to *Error, objects which are not
+// errors are reported like wrong type argument of raise.
+func raisedError(err error) error {
+	uncaught, ok := err.(*uncaughtException)
+	if !ok {
+		return err
+	}
+	if e, ok := uncaught.condition.(*Error); ok {
+		return e
+	}
+	e := errWrongType(uncaught.condition, 0, "raise")
+	e.Raised = uncaught.condition
+	return e
+}
+
+// raise calls the innermost of handlers with obj. When the raise is not
+// continuable and the handler returns, a secondary exception is raised to the
+// outer handlers.
+func raise(obj sexpr.Expr, continuable bool, handlers *handler) (sexpr.Expr, *tailCall, error) {
+	if handlers == noHandlers {
+		return nil, nil, &uncaughtException{condition: obj}
+	}
+	then := func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		if continuable {
+			return value, nil, nil
+		}
+		return raise(newError(KindHandlerReturned, "Exception handler returned from non-continuable raise:", obj), false, handlers.next)
+	}
+	return nil, &tailCall{
+		Procedure: handlers.procedure,
+		Arguments: []sexpr.Expr{obj},
+		Env:       handlers.env,
+		Then:      then,
+		Handlers:  handlers.next,
+	}, nil
+}
+
+func returnValue(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+	return value, nil, nil
+}
+
+// evalGuard evaluates (guard (var clause...) body...). When the body raises,
+// the continuation of guard binds var to the raised object and selects one of
+// clauses like cond. When none is selected, the object is raised again by
+// raise-continuable in the dynamic environment of the original raise, or in
+// the continuation of guard when the raise was within a nested machine.
+func evalGuard(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	spec, ok := sexpr.ToSlice(list[1])
+	if !ok || len(spec) == 0 {
+		return nil, nil, errIllFormed(form)
+	}
+	name, ok := bindingName(spec[0])
+	if !ok {
+		return nil, nil, errIllFormed(form)
+	}
+	clauses, body := spec[1:], list[2:]
+
+	dispatch := func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		signal, ok := value.(*guardSignal)
+		if !ok {
+			return value, nil, nil
+		}
+		clauseEnv := env.Extend()
+		clauseEnv.Define(name, signal.condition)
+		return evalCondClauses(form, clauses, clauseEnv, func() (sexpr.Expr, *tailCall, error) {
+			raiseK := signal.raiseK.frame
+			if signal.nested {
+				return raise(signal.condition, true, raiseK.handlers)
+			}
+			reraise := &frame{
+				resume: func(sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+					return raise(signal.condition, true, raiseK.handlers)
+				},
+				next:     raiseK,
+				winders:  raiseK.winders,
+				handlers: raiseK.handlers,
+				depth:    raiseK.depth + 1,
+				machine:  raiseK.machine,
+			}
+			return nil, nil, &continuationJump{target: &Continuation{frame: reraise}}
+		})
+	}
+	enter := func(_ []sexpr.Expr, k *Continuation, _ *Environment) (sexpr.Expr, *tailCall, error) {
+		catch := func(args []sexpr.Expr, raiseK *Continuation, _ *Environment) (sexpr.Expr, *tailCall, error) {
+			signal := &guardSignal{condition: args[0], raiseK: raiseK, nested: !k.frame.machine.owns(raiseK)}
+			return nil, nil, &continuationJump{target: k, value: signal}
+		}
+		evalBody := func([]sexpr.Expr, *Continuation, *Environment) (sexpr.Expr, *tailCall, error) {
+			return evalSequence(body, env.Extend())
+		}
+		return nil, &tailCall{
+			Procedure: controlProcedure(evalBody),
+			Env:       env,
+			Then:      returnValue,
+			Handlers:  &handler{procedure: controlProcedure(catch), env: env, next: k.frame.handlers},
+		}, nil
+	}
+	return nil, &tailCall{Procedure: controlProcedure(enter), Env: env, Then: dispatch}, nil
+}
+
+func addExceptionBuiltins(env *Environment) {
+	addControlToEnv(env, "with-exception-handler", withExceptionHandlerBuiltin)
+	addControlToEnv(env, "raise", func(args []sexpr.Expr, k *Continuation, env *Environment) (sexpr.Expr, *tailCall, error) {
+		if err := checkArity("raise", 1, args); err != nil {
+			return nil, nil, err
+		}
+		return raise(args[0], false, k.frame.handlers)
+	})
+	addControlToEnv(env, "raise-continuable", func(args []sexpr.Expr, k *Continuation, env *Environment) (sexpr.Expr, *tailCall, error) {
+		if err := checkArity("raise-continuable", 1, args); err != nil {
+			return nil, nil, err
+		}
+		return raise(args[0], true, k.frame.handlers)
+	})
+	AddFuncToEnv(env, "error", errorBuiltin)
+	AddFuncToEnv(env, "error-object?", predicateBuiltin("error-object?", func(e sexpr.Expr) bool {
+		_, ok := e.(*Error)
+		return ok
+	}))
+	AddFuncToEnv(env, "error-object-message", errorObjectMessageBuiltin)
+	AddFuncToEnv(env, "error-object-irritants", errorObjectIrritantsBuiltin)
+}
+
+// withExceptionHandlerBuiltin calls (with-exception-handler handler thunk),
+// handler is called with objects raised during the call of thunk.
+func withExceptionHandlerBuiltin(args []sexpr.Expr, k *Continuation, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if err := checkArity("with-exception-handler", 2, args); err != nil {
+		return nil, nil, err
+	}
+	for i, procedure := range args {
+		if !isProcedure(procedure) {
+			return nil, nil, errWrongType(procedure, i, "with-exception-handler")
+		}
+	}
+	return nil, &tailCall{
+		Procedure: args[1],
+		Env:       env,
+		Then:      returnValue,
+		Handlers:  &handler{procedure: args[0], env: env, next: k.frame.handlers},
+	}, nil
+}
+
+// errorBuiltin signals (error message irritant...), the message is printed
+// when it is not a string.
+func errorBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkMinArity("error", 1, args); err != nil {
+		return nil, err
+	}
+	message, ok := args[0].(string)
+	if !ok {
+		message = sexpr.Print(args[0])
+	}
+	irritants := make([]sexpr.Expr, len(args)-1)
+	copy(irritants, args[1:])
+	return nil, newError(KindSimpleError, message, irritants...)
+}
+
+func errorObjectMessageBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("error-object-message", 1, args); err != nil {
+		return nil, err
+	}
+	e, ok := args[0].(*Error)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "error-object-message")
+	}
+	return e.Message, nil
+}
+
+func errorObjectIrritantsBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("error-object-irritants", 1, args); err != nil {
+		return nil, err
+	}
+	e, ok := args[0].(*Error)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "error-object-irritants")
+	}
+	return sexpr.List(e.Irritants...), nil
+}
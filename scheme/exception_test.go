@@ -0,0 +1,134 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestExceptions(t *testing.T) {
+	t.Run("guard catches raised object", func(t *testing.T) {
+		assert.Equal(t, sexpr.List(sexpr.Symbol("caught"), 42), mustEval(t, `
+			(guard (e (#t (list 'caught e)))
+				(+ 1 (raise 42)))`))
+		assert.Equal(t, sexpr.Symbol("b"), mustEval(t, `
+			(guard (e ((symbol? e) e) ((number? e) 'number))
+				(raise 'b))`))
+		assert.Equal(t, 3, mustEval(t, `(guard (e (#f 0)) (+ 1 2))`))
+		assert.Equal(t, 42, mustEval(t, `
+			(guard (e ((assq 'a e) => cdr) ((assq 'b e)))
+				(raise (list (cons 'a 42))))`))
+	})
+
+	t.Run("guard catches errors of builtins", func(t *testing.T) {
+		assert.Equal(t, true, mustEval(t, `(guard (e ((error-object? e) #t)) (car '()))`))
+		assert.Equal(t, sexpr.List(sexpr.List()), mustEval(t, `
+			(guard (e (#t (error-object-irritants e)))
+				(vector-map (lambda (x) (car x)) #(())))`))
+		assert.Equal(t, "#[condition wrong-type-argument]", sexpr.Print(mustEval(t, `
+			(guard (e (else e)) (vector-ref #(1) 'a))`)))
+	})
+
+	t.Run("error objects", func(t *testing.T) {
+		assert.Equal(t, sexpr.List("Something bad:", sexpr.List(1, sexpr.Symbol("a"))), mustEval(t, `
+			(guard (e ((error-object? e)
+			           (list (error-object-message e) (error-object-irritants e))))
+				(error "Something bad:" 1 'a))`))
+		assert.Equal(t, false, mustEval(t, `(error-object? 'a)`))
+	})
+
+	t.Run("guard without selected clause raises again", func(t *testing.T) {
+		assert.Equal(t, sexpr.Symbol("outer"), mustEval(t, `
+			(guard (e ((eq? e 'x) 'outer))
+				(guard (e ((eq? e 'y) 'inner))
+					(raise 'x)))`))
+		// the outer handler returns to raise-continuable
+		assert.Equal(t, 11, mustEval(t, `
+			(with-exception-handler
+				(lambda (e) 10)
+				(lambda ()
+					(guard (e (#f 0))
+						(+ 1 (raise-continuable 'oops)))))`))
+	})
+
+	t.Run("with-exception-handler", func(t *testing.T) {
+		assert.Equal(t, 65, mustEval(t, `
+			(with-exception-handler
+				(lambda (con) (if (symbol? con) 23 42))
+				(lambda () (+ (raise-continuable 'not-a-number) 42)))`))
+		assert.Equal(t, sexpr.List(sexpr.Symbol("escaped"), sexpr.Symbol("boom")), mustEval(t, `
+			(call/cc
+				(lambda (k)
+					(with-exception-handler
+						(lambda (e) (k (list 'escaped e)))
+						(lambda () (raise 'boom)))))`))
+	})
+
+	t.Run("handler runs with outer handlers", func(t *testing.T) {
+		assert.Equal(t, sexpr.List(sexpr.Symbol("outer"), sexpr.Symbol("inner")), mustEval(t, `
+			(guard (e (#t (list 'outer e)))
+				(with-exception-handler
+					(lambda (e) (raise 'inner))
+					(lambda () (raise 'first))))`))
+	})
+
+	t.Run("procedures called by builtins run with current handlers", func(t *testing.T) {
+		assert.Equal(t, "#(11)", sexpr.Print(mustEval(t, `
+			(with-exception-handler
+				(lambda (e) 10)
+				(lambda ()
+					(vector-map (lambda (x) (+ 1 (raise-continuable 'c))) #(1))))`)))
+		assert.Equal(t, KindHandlerReturned, evalError(t, `
+			(with-exception-handler (lambda (e) 0) (lambda () (vector-map car #(1))))`).Kind)
+		// the nested call has returned, so guard raises again in its own
+		// continuation
+		assert.Equal(t, 10, mustEval(t, `
+			(with-exception-handler
+				(lambda (e) 10)
+				(lambda ()
+					(guard (e (#f 0))
+						(vector-map (lambda (x) (+ 1 (raise-continuable 'c))) #(1)))))`))
+
+		result, _, err := EvalBuffer(`
+			(define-macro (ask) (list 'quote (raise-continuable 'which)))
+			(with-exception-handler (lambda (e) 5) (lambda () (+ 1 (ask))))`,
+			DefaultEnvironment())
+		assert.NoError(t, err)
+		assert.Equal(t, 6, result)
+	})
+
+	t.Run("leaving guard calls after thunks", func(t *testing.T) {
+		assert.Equal(t, "(caught (after))", sexpr.Print(mustEval(t, `
+			(let ((path '()))
+				(let ((result (guard (e (#t 'caught))
+				                (dynamic-wind
+				                  (lambda () #f)
+				                  (lambda () (raise 'oops))
+				                  (lambda () (set! path (cons 'after path)))))))
+					(list result path)))`)))
+	})
+
+	t.Run("uncaught", func(t *testing.T) {
+		// arrange
+		err := evalError(t, `(raise 'oops)`)
+
+		// assert
+		assert.Equal(t, KindWrongType, err.Kind)
+		assert.Equal(t, sexpr.Symbol("oops"), err.Raised)
+
+		assert.Equal(t, KindSimpleError, evalError(t, `(error "bad thing")`).Kind)
+		assert.Equal(t, KindWrongType, evalError(t, `(guard (e ((symbol? e) e)) (car 1))`).Kind)
+		assert.Equal(t, KindHandlerReturned, evalError(t, `
+			(with-exception-handler (lambda (e) 0) (lambda () (car 1)))`).Kind)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assert.Equal(t, KindSyntax, evalError(t, `(guard (1) 2)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(guard (e))`).Kind)
+		assert.Equal(t, KindWrongType, evalError(t, `(with-exception-handler 1 (lambda () 1))`).Kind)
+		assert.Equal(t, KindArity, evalError(t, `(raise)`).Kind)
+		assert.Equal(t, KindWrongType, evalError(t, `(error-object-message 'a)`).Kind)
+	})
+}
@@ -0,0 +1,181 @@
+package scheme
+
+import (
+	"io"
+	"os"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// Interpreter evaluates programs in its own global environment, so
+// definitions of one interpreter are not visible to others. Interpreter is
+// not safe for concurrent use, but different interpreters may be used
+// concurrently.
+type Interpreter struct {
+	env    *Environment
+	stdout io.Writer
+	stderr io.Writer
+	// maxSteps and maxDepth limit every toplevel evaluation, zero means
+	// no limit.
+	maxSteps int
+	maxDepth int
+	// steps are counted from the start of current toplevel evaluation.
+	steps int
+	// current is the continuation of Go code called by the innermost
+	// running machine, nested machines started by the code continue it.
+	current *frame
+}
+
+// Option configures Interpreter created by NewInterpreter.
+type Option func(interp *Interpreter)
+
+// WithStdout sets writer of current output port, it is os.Stdout by default.
+func WithStdout(w io.Writer) Option {
+	return func(interp *Interpreter) {
+		interp.stdout = w
+	}
+}
+
+// WithStderr sets writer of current error port, it is os.Stderr by default.
+func WithStderr(w io.Writer) Option {
+	return func(interp *Interpreter) {
+		interp.stderr = w
+	}
+}
+
+// WithBindings defines variables in global environment like Define.
+func WithBindings(bindings map[string]sexpr.Expr) Option {
+	return func(interp *Interpreter) {
+		for name, value := range bindings {
+			interp.Define(name, value)
+		}
+	}
+}
+
+// WithStepLimit aborts evaluation which takes more than steps, so a program
+// can not loop forever. Every element of vectors and bytevectors made by
+// builtins counts as a step, so the limit bounds memory allocated by them
+// too.
+func WithStepLimit(steps int) Option {
+	return func(interp *Interpreter) {
+		interp.maxSteps = steps
+	}
+}
+
+// WithDepthLimit aborts evaluation which waits for more than depth values at
+// once, for example non-tail recursion deeper than depth, including calls of
+// procedures by builtins like vector-map.
+func WithDepthLimit(depth int) Option {
+	return func(interp *Interpreter) {
+		interp.maxDepth = depth
+	}
+}
+
+// NewInterpreter returns interpreter with builtin procedures.
+func NewInterpreter(options ...Option) *Interpreter {
+	interp := &Interpreter{
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+	interp.env = EmptyEnvironment()
+	interp.env.interpreter = interp
+	addBultin(interp.env)
+	for _, option := range options {
+		option(interp)
+	}
+	return interp
+}
+
+// Environment returns global environment of the interpreter.
+func (interp *Interpreter) Environment() *Environment {
+	return interp.env
+}
+
+// Eval evaluates expression in global environment.
+func (interp *Interpreter) Eval(expr sexpr.Expr) (sexpr.Expr, error) {
+	return evalToplevel(expr, interp.env)
+}
+
+// EvalString evaluates all expressions of program and returns the value of
+// the last one.
+func (interp *Interpreter) EvalString(program string) (sexpr.Expr, error) {
+	result, _, err := EvalBuffer(program, interp.env)
+	return result, err
+}
+
+// Load evaluates all expressions of file and returns the value of the last
+// one.
+func (interp *Interpreter) Load(filename string) (sexpr.Expr, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var result sexpr.Expr
+	reader := sexpr.NewReader(file)
+	for {
+		expr, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		result, err = interp.Eval(expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Define binds name in global environment. Go functions with signature of
+// Procedure are defined as primitive procedures named name.
+func (interp *Interpreter) Define(name string, value sexpr.Expr) {
+	switch f := value.(type) {
+	case func([]sexpr.Expr, *Environment) (sexpr.Expr, error):
+		value = &Primitive{Name: sexpr.Symbol(name), Procedure: f}
+	case Procedure:
+		value = &Primitive{Name: sexpr.Symbol(name), Procedure: f}
+	}
+	interp.env.Define(sexpr.Symbol(name), value)
+}
+
+// Lookup returns value of name in global environment.
+func (interp *Interpreter) Lookup(name string) (sexpr.Expr, bool) {
+	return interp.env.Lookup(sexpr.Symbol(name))
+}
+
+// Call applies procedure to arguments, procedure given as a symbol is looked
+// up in global environment.
+func (interp *Interpreter) Call(procedure sexpr.Expr, args ...sexpr.Expr) (sexpr.Expr, error) {
+	if name, ok := procedure.(sexpr.Symbol); ok {
+		value, ok := interp.env.Lookup(name)
+		if !ok {
+			return nil, errUnboundVariable(name)
+		}
+		procedure = value
+	}
+	return runToplevel(&tailCall{Procedure: procedure, Arguments: args, Env: interp.env})
+}
+
+// allocate counts elements of a new object as steps.
+func (interp *Interpreter) allocate(elements int) error {
+	interp.steps += elements
+	if interp.maxSteps > 0 && interp.steps > interp.maxSteps {
+		return newError(KindLimit, "Aborting!: maximum number of steps exceeded")
+	}
+	return nil
+}
+
+// checkLimits counts step of evaluation with continuation k.
+func (interp *Interpreter) checkLimits(k *frame) error {
+	interp.steps++
+	if interp.maxSteps > 0 && interp.steps > interp.maxSteps {
+		return newError(KindLimit, "Aborting!: maximum number of steps exceeded")
+	}
+	if interp.maxDepth > 0 && k.depth > interp.maxDepth {
+		return newError(KindLimit, "Aborting!: maximum recursion depth exceeded")
+	}
+	return nil
+}
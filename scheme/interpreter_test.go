@@ -0,0 +1,184 @@
+package scheme
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestInterpreter(t *testing.T) {
+	t.Run("interpreters do not share definitions", func(t *testing.T) {
+		// arrange
+		first := NewInterpreter()
+		second := NewInterpreter()
+
+		// act
+		_, err := first.EvalString(`(define x 1)`)
+		assert.NoError(t, err)
+		_, secondErr := second.EvalString(`x`)
+
+		// assert
+		value, ok := first.Lookup("x")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		_, ok = second.Lookup("x")
+		assert.False(t, ok)
+		assert.Equal(t, KindUnboundVariable, secondErr.(*Error).Kind)
+	})
+
+	t.Run("interpreters run concurrently", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([]sexpr.Expr, 4)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				interp := NewInterpreter(WithBindings(map[string]sexpr.Expr{"n": i}))
+				results[i], _ = interp.EvalString(`
+					(define (sum n) (if (= n 0) 0 (+ n (sum (- n 1)))))
+					(sum (* n 100))`)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, []sexpr.Expr{0, 5050, 20100, 45150}, results)
+	})
+
+	t.Run("EvalString returns the value of the last expression", func(t *testing.T) {
+		result, err := NewInterpreter().EvalString(`(define a 2) (define b 3) (* a b)`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 6, result)
+	})
+
+	t.Run("Eval evaluates datum", func(t *testing.T) {
+		result, err := NewInterpreter().Eval(sexpr.List(sexpr.Symbol("+"), 1, 2))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result)
+	})
+
+	t.Run("Define and Call", func(t *testing.T) {
+		// arrange
+		interp := NewInterpreter()
+		interp.Define("offset", 10)
+		interp.Define("twice", func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+			return args[0].(int) * 2, nil
+		})
+		_, err := interp.EvalString(`(define (shift x) (+ (twice x) offset))`)
+		assert.NoError(t, err)
+		square, err := interp.EvalString(`(lambda (x) (* x x))`)
+		assert.NoError(t, err)
+
+		// act
+		shifted, err := interp.Call(sexpr.Symbol("shift"), 5)
+		assert.NoError(t, err)
+		squared, err := interp.Call(square, 5)
+		assert.NoError(t, err)
+
+		// assert
+		assert.Equal(t, 20, shifted)
+		assert.Equal(t, 25, squared)
+		twice, _ := interp.Lookup("twice")
+		assert.Equal(t, "#[procedure twice]", sexpr.Print(twice))
+		_, err = interp.Call(sexpr.Symbol("missing"))
+		assert.Equal(t, KindUnboundVariable, err.(*Error).Kind)
+		_, err = interp.Call(1)
+		assert.Equal(t, KindNotApplicable, err.(*Error).Kind)
+	})
+
+	t.Run("Load", func(t *testing.T) {
+		// arrange
+		filename := filepath.Join(t.TempDir(), "program.scm")
+		assert.NoError(t, os.WriteFile(filename, []byte(`
+			; definitions span several lines
+			(define (fact n)
+				(if (= n 0) 1 (* n (fact (- n 1)))))
+			(fact 5)
+		`), 0o600))
+		interp := NewInterpreter()
+
+		// act
+		result, err := interp.Load(filename)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 120, result)
+		_, ok := interp.Lookup("fact")
+		assert.True(t, ok)
+		_, err = interp.Load(filepath.Join(t.TempDir(), "missing.scm"))
+		assert.Error(t, err)
+	})
+
+	t.Run("output ports", func(t *testing.T) {
+		// arrange
+		var stdout, stderr strings.Builder
+		interp := NewInterpreter(WithStdout(&stdout), WithStderr(&stderr))
+
+		// act
+		_, err := interp.EvalString(`
+			(display "hello")
+			(write-char #\space)
+			(write '("world" #\!))
+			(newline)
+			(write-string "oops" (current-error-port))`)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "hello (\"world\" #\\!)\n", stdout.String())
+		assert.Equal(t, "oops", stderr.String())
+		_, err = interp.EvalString(`(write-string 'a)`)
+		assert.Equal(t, KindWrongType, err.(*Error).Kind)
+		_, err = interp.EvalString(`(display 1 2)`)
+		assert.Equal(t, KindWrongType, err.(*Error).Kind)
+	})
+
+	t.Run("step limit", func(t *testing.T) {
+		// arrange
+		interp := NewInterpreter(WithStepLimit(10000))
+
+		// act
+		_, err := interp.EvalString(`(guard (e (#t 'caught)) (let loop () (loop)))`)
+		_, allocateErr := interp.EvalString(`(make-vector 20000)`)
+		_, appendErr := interp.EvalString(`
+			(let loop ((v (make-vector 1000)))
+				(loop (vector-append v v)))`)
+		result, nextErr := interp.EvalString(`(+ 1 2)`)
+
+		// assert
+		assert.Equal(t, KindLimit, err.(*Error).Kind)
+		assert.Equal(t, KindLimit, allocateErr.(*Error).Kind, "elements count as steps")
+		assert.Equal(t, KindLimit, appendErr.(*Error).Kind)
+		assert.NoError(t, nextErr, "steps are counted afresh for every evaluation")
+		assert.Equal(t, 3, result)
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		// arrange
+		interp := NewInterpreter(WithDepthLimit(1000))
+		_, err := interp.EvalString(`(define (count n) (if (= n 0) 0 (+ 1 (count (- n 1)))))`)
+		assert.NoError(t, err)
+
+		_, err = interp.EvalString(`
+			(define (nested n)
+				(if (= n 0) 0 (+ 1 (vector-ref (vector-map nested (vector (- n 1))) 0))))`)
+		assert.NoError(t, err)
+
+		// act
+		_, deepErr := interp.EvalString(`(count 10000)`)
+		_, nestedErr := interp.EvalString(`(nested 3000000)`)
+		result, err := interp.EvalString(`(let loop ((i 0)) (if (= i 10000) i (loop (+ i 1))))`)
+
+		// assert
+		assert.Equal(t, KindLimit, deepErr.(*Error).Kind)
+		assert.Equal(t, KindLimit, nestedErr.(*Error).Kind, "calls by builtins count to depth")
+		assert.NoError(t, err)
+		assert.Equal(t, 10000, result)
+	})
+}
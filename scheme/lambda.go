@@ -0,0 +1,322 @@
+package scheme
+
+import (
+	"strconv"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// Markers of parameter list sections, for example
+// (lambda (a #!optional (b 2) #!rest r #!key c) ...).
+const (
+	optionalMarker = sexpr.Symbol("#!optional")
+	restMarker     = sexpr.Symbol("#!rest")
+	keyMarker      = sexpr.Symbol("#!key")
+)
+
+// DefaultObject is bound to optional parameters which are not supplied and
+// have no default expression.
+type DefaultObject struct{}
+
+func (DefaultObject) String() string {
+	return "#!default"
+}
+
+// OptionalParameter is a parameter after #!optional or #!key.
+type OptionalParameter struct {
+	Name sexpr.Symbol
+	// Default is evaluated when argument is not supplied, nil means that
+	// DefaultObject is bound.
+	Default sexpr.Expr
+}
+
+// Lambda is a compound procedure. Arguments are bound in order: required
+// Parameters, Optional ones, Rest gets the list of remaining arguments and
+// Keys are looked up in remaining arguments written as `name: value`.
+type Lambda struct {
+	Name       sexpr.Symbol
+	Env        *Environment
+	Parameters []sexpr.Symbol
+	Optional   []OptionalParameter
+	Rest       sexpr.Symbol
+	Keys       []OptionalParameter
+	Body       []sexpr.Expr
+}
+
+func (lambda *Lambda) String() string {
+	name := lambda.Name
+	if name == "" {
+		name = "anonymous"
+	}
+	return "#[compound-procedure " + string(name) + "]"
+}
+
+// parameterNames returns names of all parameters of lambda.
+func (lambda *Lambda) parameterNames() []sexpr.Symbol {
+	names := append([]sexpr.Symbol(nil), lambda.Parameters...)
+	for _, parameter := range lambda.Optional {
+		names = append(names, parameter.Name)
+	}
+	if lambda.Rest != "" {
+		names = append(names, lambda.Rest)
+	}
+	for _, parameter := range lambda.Keys {
+		names = append(names, parameter.Name)
+	}
+	return names
+}
+
+// makeLambda parses formals, which are a symbol for variadic procedure or a
+// possibly improper list of parameters. The environment is captured by
+// reference, so procedures defined later in the same body are visible.
+func makeLambda(formals sexpr.Expr, body []sexpr.Expr, env *Environment) (*Lambda, error) {
+	lambda := &Lambda{
+		Env:  env,
+		Body: body,
+	}
+	seen := make(map[sexpr.Symbol]bool)
+	declare := func(name sexpr.Expr) (sexpr.Symbol, error) {
+		symbol, ok := bindingName(name)
+		if !ok || symbol == optionalMarker || symbol == restMarker || symbol == keyMarker || seen[symbol] {
+			return "", newError(KindSyntax, "Invalid parameter:", name)
+		}
+		seen[symbol] = true
+		return symbol, nil
+	}
+	optional := func(parameter sexpr.Expr) (OptionalParameter, error) {
+		if definition, ok := sexpr.ToSlice(parameter); ok && len(definition) == 2 {
+			name, err := declare(definition[0])
+			return OptionalParameter{Name: name, Default: definition[1]}, err
+		}
+		name, err := declare(parameter)
+		return OptionalParameter{Name: name}, err
+	}
+
+	section := sexpr.Symbol("")
+	for formals != sexpr.Nil {
+		pair, ok := formals.(*sexpr.Pair)
+		if !ok {
+			// (a b . rest) or just args
+			if lambda.Rest != "" {
+				return nil, newError(KindSyntax, "Invalid parameter:", formals)
+			}
+			rest, err := declare(formals)
+			if err != nil {
+				return nil, err
+			}
+			lambda.Rest = rest
+			break
+		}
+		formals = pair.Cdr
+
+		switch parameter := pair.Car; {
+		case parameter == optionalMarker && section == "",
+			parameter == restMarker && (section == "" || section == optionalMarker),
+			parameter == keyMarker && section != keyMarker:
+			section = parameter.(sexpr.Symbol)
+		case section == "":
+			name, err := declare(parameter)
+			if err != nil {
+				return nil, err
+			}
+			lambda.Parameters = append(lambda.Parameters, name)
+		case section == optionalMarker:
+			parameter, err := optional(parameter)
+			if err != nil {
+				return nil, err
+			}
+			lambda.Optional = append(lambda.Optional, parameter)
+		case section == restMarker && lambda.Rest == "":
+			name, err := declare(parameter)
+			if err != nil {
+				return nil, err
+			}
+			lambda.Rest = name
+		case section == keyMarker:
+			parameter, err := optional(parameter)
+			if err != nil {
+				return nil, err
+			}
+			lambda.Keys = append(lambda.Keys, parameter)
+		default:
+			return nil, newError(KindSyntax, "Invalid parameter:", parameter)
+		}
+	}
+	if section == restMarker && lambda.Rest == "" {
+		return nil, newError(KindSyntax, "Missing rest parameter after", restMarker)
+	}
+	return lambda, nil
+}
+
+// isKeyword reports whether symbol is a keyword like `width:`, keywords
+// evaluate to themselves unless they are bound.
+func isKeyword(symbol sexpr.Symbol) bool {
+	return len(symbol) > 1 && symbol[len(symbol)-1] == ':'
+}
+
+// arity describes the number of accepted arguments for error messages.
+func (lambda *Lambda) arity() string {
+	required := len(lambda.Parameters)
+	switch {
+	case lambda.Rest != "" || len(lambda.Keys) > 0:
+		return "at least " + strconv.Itoa(required)
+	case len(lambda.Optional) > 0:
+		return strconv.Itoa(required) + " to " + strconv.Itoa(required+len(lambda.Optional))
+	}
+	return strconv.Itoa(required)
+}
+
+// accepts reports whether lambda can be called with count arguments.
+func (lambda *Lambda) accepts(count int) bool {
+	required := len(lambda.Parameters)
+	if lambda.Rest != "" || len(lambda.Keys) > 0 {
+		return count >= required
+	}
+	return count >= required && count <= required+len(lambda.Optional)
+}
+
+// argument is a value matched to parameter of lambda, or the default
+// expression of optional parameter which is not supplied.
+type argument struct {
+	Name     sexpr.Symbol
+	Value    sexpr.Expr
+	Default  sexpr.Expr
+	Supplied bool
+}
+
+// bindArguments binds arguments to parameters in a new frame enclosed by the
+// environment of lambda and passes it to then. Defaults of parameters which
+// are not supplied are evaluated in order, so they may refer to previous
+// parameters.
+func (lambda *Lambda) bindArguments(arguments []sexpr.Expr, then func(env *Environment) (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	if len(arguments) < len(lambda.Parameters) {
+		return nil, nil, errArity(lambda.String(), lambda.arity(), len(arguments))
+	}
+	env := lambda.Env.Extend()
+	if len(lambda.Optional) == 0 && len(lambda.Keys) == 0 {
+		for i, parameter := range lambda.Parameters {
+			env.Define(parameter, arguments[i])
+		}
+		rest := arguments[len(lambda.Parameters):]
+		if lambda.Rest != "" {
+			env.Define(lambda.Rest, sexpr.List(rest...))
+		} else if len(rest) > 0 {
+			return nil, nil, errArity(lambda.String(), lambda.arity(), len(arguments))
+		}
+		return then(env)
+	}
+	matched, err := lambda.matchArguments(arguments)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bindMatched(env, matched, then)
+}
+
+// matchArguments matches arguments to parameters in order of binding.
+func (lambda *Lambda) matchArguments(arguments []sexpr.Expr) ([]argument, error) {
+	matched := make([]argument, 0, len(lambda.Parameters)+len(lambda.Optional)+len(lambda.Keys)+1)
+	for i, parameter := range lambda.Parameters {
+		matched = append(matched, argument{Name: parameter, Value: arguments[i], Supplied: true})
+	}
+	rest := arguments[len(lambda.Parameters):]
+	for _, parameter := range lambda.Optional {
+		if len(rest) == 0 {
+			matched = append(matched, argument{Name: parameter.Name, Default: parameter.Default})
+			continue
+		}
+		matched = append(matched, argument{Name: parameter.Name, Value: rest[0], Supplied: true})
+		rest = rest[1:]
+	}
+
+	if lambda.Rest != "" {
+		matched = append(matched, argument{Name: lambda.Rest, Value: sexpr.List(rest...), Supplied: true})
+	}
+	if len(lambda.Keys) > 0 {
+		return lambda.matchKeys(matched, rest)
+	}
+	if lambda.Rest == "" && len(rest) > 0 {
+		return nil, errArity(lambda.String(), lambda.arity(), len(arguments))
+	}
+	return matched, nil
+}
+
+// matchKeys matches keyword arguments written as `name: value`.
+func (lambda *Lambda) matchKeys(matched []argument, arguments []sexpr.Expr) ([]argument, error) {
+	if len(arguments)%2 != 0 {
+		return nil, newError(KindSyntax, "Keyword argument list has odd length:", sexpr.List(arguments...))
+	}
+	known := make(map[sexpr.Symbol]bool, len(lambda.Keys))
+	for _, parameter := range lambda.Keys {
+		known[parameter.Name] = true
+	}
+	// the first occurrence of keyword wins
+	supplied := make(map[sexpr.Symbol]sexpr.Expr, len(arguments)/2)
+	for i := 0; i < len(arguments); i += 2 {
+		keyword, ok := arguments[i].(sexpr.Symbol)
+		if !ok || !isKeyword(keyword) {
+			return nil, errWrongType(arguments[i], len(lambda.Parameters)+len(lambda.Optional)+i, lambda.String())
+		}
+		name := keyword[:len(keyword)-1]
+		if !known[name] && lambda.Rest == "" {
+			return nil, newError(KindBadRange, "Unknown keyword argument:", keyword)
+		}
+		if _, ok := supplied[name]; !ok {
+			supplied[name] = arguments[i+1]
+		}
+	}
+	for _, parameter := range lambda.Keys {
+		value, ok := supplied[parameter.Name]
+		if !ok {
+			matched = append(matched, argument{Name: parameter.Name, Default: parameter.Default})
+			continue
+		}
+		matched = append(matched, argument{Name: parameter.Name, Value: value, Supplied: true})
+	}
+	return matched, nil
+}
+
+// bindMatched defines matched arguments in env evaluating defaults of those
+// which are not supplied, DefaultObject is bound when there is no default.
+func bindMatched(env *Environment, matched []argument, then func(env *Environment) (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	for i, arg := range matched {
+		switch {
+		case arg.Supplied:
+			env.Define(arg.Name, arg.Value)
+		case arg.Default == nil:
+			env.Define(arg.Name, DefaultObject{})
+		default:
+			name, remains := arg.Name, matched[i+1:]
+			return evalThen(arg.Default, env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+				env.Define(name, value)
+				return bindMatched(env, remains, then)
+			})
+		}
+	}
+	return then(env)
+}
+
+// tailCall is an expression in tail position which evaluation is left to the
+// machine, so tail calls run in constant Go stack.
+type tailCall struct {
+	Expr sexpr.Expr
+	Env  *Environment
+	// Procedure is applied to Arguments instead of evaluation of Expr when it
+	// is set.
+	Procedure sexpr.Expr
+	Arguments []sexpr.Expr
+	// Then is pushed to the continuation to receive the value, so it is not
+	// in tail position anymore.
+	Then func(value sexpr.Expr) (sexpr.Expr, *tailCall, error)
+	// Wind is set when Then leaves extent of dynamic-wind, Procedure is
+	// called within the extent.
+	Wind *winder
+	// Handlers replace exception handlers for Procedure, it is set only
+	// together with Then.
+	Handlers *handler
+}
+
+func applyLambda(lambda *Lambda, arguments []sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+	return lambda.bindArguments(arguments, func(argEnv *Environment) (sexpr.Expr, *tailCall, error) {
+		return evalSequence(lambda.Body, argEnv)
+	})
+}
@@ -0,0 +1,225 @@
+package scheme
+
+import (
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// binding is a parsed (name init) pair of let-family binding list.
+type binding struct {
+	ID sexpr.Expr
+	// Name is the symbol under which ID is bound.
+	Name sexpr.Symbol
+	Init sexpr.Expr
+}
+
+// parseBindings parses ((name init) ...) of let, let*, letrec and letrec*.
+func parseBindings(form sexpr.Expr, bindings sexpr.Expr, unique bool) ([]binding, error) {
+	list, ok := sexpr.ToSlice(bindings)
+	if !ok {
+		return nil, errIllFormed(form)
+	}
+	seen := make(map[sexpr.Symbol]bool, len(list))
+	result := make([]binding, 0, len(list))
+	for _, b := range list {
+		pair, ok := sexpr.ToSlice(b)
+		if !ok || len(pair) != 2 {
+			return nil, errIllFormed(form)
+		}
+		name, ok := bindingName(pair[0])
+		if !ok || (unique && seen[name]) {
+			return nil, errIllFormed(form)
+		}
+		seen[name] = true
+		result = append(result, binding{ID: pair[0], Name: name, Init: pair[1]})
+	}
+	return result, nil
+}
+
+// evalLet evaluates (let ((name init) ...) body...) and named let
+// (let loop ((name init) ...) body...) where loop is bound to procedure with
+// the body, so it can be called in tail position to iterate.
+func evalLet(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	name, named := bindingName(list[1])
+	if named {
+		list = list[1:]
+		if len(list) < 3 {
+			return nil, nil, errIllFormed(form)
+		}
+	}
+	bindings, err := parseBindings(form, list[1], true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inits := make([]sexpr.Expr, len(bindings))
+	for i, b := range bindings {
+		inits[i] = b.Init
+	}
+	body := list[2:]
+	return evalEach(inits, make([]sexpr.Expr, 0, len(inits)), env, func(arguments []sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return bindLet(list[0], name, named, bindings, arguments, body, env)
+	})
+}
+
+// bindLet binds values of let bindings and evaluates the body, named let
+// binds procedure with the body and calls it.
+func bindLet(id sexpr.Expr, name sexpr.Symbol, named bool, bindings []binding, arguments []sexpr.Expr, body []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if !named {
+		env = env.Extend()
+		for i, b := range bindings {
+			env.Define(b.Name, arguments[i])
+		}
+		return evalSequence(body, env)
+	}
+
+	parameters := make([]sexpr.Symbol, len(bindings))
+	for i, b := range bindings {
+		parameters[i] = b.Name
+	}
+	loopEnv := env.Extend()
+	loop := &Lambda{
+		Name:       baseName(id),
+		Env:        loopEnv,
+		Parameters: parameters,
+		Body:       body,
+	}
+	loopEnv.Define(name, loop)
+	return applyLambda(loop, arguments)
+}
+
+// evalLetStar evaluates (let* ((name init) ...) body...) where every init
+// sees previous bindings.
+func evalLetStar(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	bindings, err := parseBindings(form, list[1], false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bindLetStar(bindings, list[2:], env.Extend())
+}
+
+// bindLetStar evaluates the first of bindings and binds it in a new frame
+// for the rest ones.
+func bindLetStar(bindings []binding, body []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(bindings) == 0 {
+		return evalSequence(body, env)
+	}
+	b := bindings[0]
+	return evalThen(b.Init, env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		env := env.Extend()
+		env.Define(b.Name, value)
+		return bindLetStar(bindings[1:], body, env)
+	})
+}
+
+// evalLetrec evaluates letrec and letrec* where inits are evaluated in the
+// environment which contains all bindings, so procedures can be mutually
+// recursive. letrec* binds every value as soon as it is evaluated, letrec
+// binds them after all inits are evaluated.
+func evalLetrec(form sexpr.Expr, list []sexpr.Expr, env *Environment, sequential bool) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	bindings, err := parseBindings(form, list[1], true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bindLetrec(bindings, make([]sexpr.Expr, 0, len(bindings)), list[2:], env.Extend(), sequential)
+}
+
+// bindLetrec evaluates inits of bindings which are not evaluated yet and
+// binds their values.
+func bindLetrec(bindings []binding, values []sexpr.Expr, body []sexpr.Expr, env *Environment, sequential bool) (sexpr.Expr, *tailCall, error) {
+	if len(values) == len(bindings) {
+		for i, b := range bindings {
+			env.Define(b.Name, values[i])
+		}
+		return evalSequence(body, env)
+	}
+	b := bindings[len(values)]
+	return evalThen(b.Init, env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		if lambda, ok := value.(*Lambda); ok && lambda.Name == "" {
+			lambda.Name = baseName(b.ID)
+		}
+		if sequential {
+			env.Define(b.Name, value)
+		}
+		next := make([]sexpr.Expr, len(values)+1, len(bindings))
+		copy(next, values)
+		next[len(values)] = value
+		return bindLetrec(bindings, next, body, env, sequential)
+	})
+}
+
+// evalLetValues evaluates (let-values (((a b . rest) init) ...) body...)
+// where each init returns multiple values bound to formals like lambda
+// parameters.
+func evalLetValues(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	bindings, ok := sexpr.ToSlice(list[1])
+	if !ok {
+		return nil, nil, errIllFormed(form)
+	}
+	formals := make([]*Lambda, len(bindings))
+	inits := make([]sexpr.Expr, len(bindings))
+	for i, b := range bindings {
+		pair, ok := sexpr.ToSlice(b)
+		if !ok || len(pair) != 2 {
+			return nil, nil, errIllFormed(form)
+		}
+		lambda, err := makeLambda(pair[0], nil, env)
+		if err != nil {
+			return nil, nil, withExpr(err, form)
+		}
+		lambda.Name = "let-values"
+		formals[i], inits[i] = lambda, pair[1]
+	}
+	seen := make(map[sexpr.Symbol]bool)
+	for _, lambda := range formals {
+		for _, name := range lambda.parameterNames() {
+			if seen[name] {
+				return nil, nil, errIllFormed(form)
+			}
+			seen[name] = true
+		}
+	}
+	return bindLetValues(form, formals, inits, list[2:], env, nil)
+}
+
+// bindLetValues evaluates the first of inits and binds its values to formals,
+// frames of bound formals are collected in bound. The body is evaluated in a
+// new frame with all of them when all inits are bound.
+func bindLetValues(form sexpr.Expr, formals []*Lambda, inits []sexpr.Expr, body []sexpr.Expr, env *Environment, bound []*Environment) (sexpr.Expr, *tailCall, error) {
+	if len(inits) == 0 {
+		extension := env.Extend()
+		for _, frame := range bound {
+			for name, value := range frame.vars {
+				extension.Define(name, value)
+			}
+		}
+		return evalSequence(body, extension)
+	}
+	return evalThen(inits[0], env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		values := valuesToSlice(value)
+		if !formals[0].accepts(len(values)) {
+			err := newError(KindArity, "Wrong number of values received by let-values, expected "+formals[0].arity()+", got:", len(values))
+			err.Expr = form
+			return nil, nil, err
+		}
+		result, tail, err := formals[0].bindArguments(values, func(argEnv *Environment) (sexpr.Expr, *tailCall, error) {
+			// copy, so the frame may be resumed again
+			next := make([]*Environment, len(bound)+1)
+			copy(next, bound)
+			next[len(bound)] = argEnv
+			return bindLetValues(form, formals[1:], inits[1:], body, env, next)
+		})
+		return result, tail, withExpr(err, form)
+	})
+}
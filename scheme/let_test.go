@@ -0,0 +1,97 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestLet(t *testing.T) {
+	t.Run("let", func(t *testing.T) {
+		assert.Equal(t, 3, mustEval(t, `(let ((a 1) (b 2)) (+ a b))`))
+		assert.Equal(t, 2, mustEval(t, `(let () 1 2)`))
+		// inits are evaluated in outer environment
+		assert.Equal(t, sexpr.List(2, 1), mustEval(t, `
+			(let ((x 1) (y 2))
+				(let ((x y) (y x))
+					(list x y)))`))
+	})
+
+	t.Run("let body has local defines", func(t *testing.T) {
+		assert.Equal(t, 15, mustEval(t, `
+			(let ((x 5))
+				(define (triple n) (* 3 n))
+				(triple x))`))
+		assert.Equal(t, KindUnboundVariable, evalError(t, `triple`).Kind)
+	})
+
+	t.Run("let*", func(t *testing.T) {
+		assert.Equal(t, 6, mustEval(t, `(let* ((a 1) (b (+ a 1)) (c (* b 3))) c)`))
+		assert.Equal(t, 2, mustEval(t, `(let* ((a 1) (a (+ a 1))) a)`))
+	})
+
+	t.Run("letrec", func(t *testing.T) {
+		assert.Equal(t, sexpr.Symbol("even"), mustEval(t, `
+			(letrec ((ev? (lambda (n) (if (= n 0) 'even (od? (- n 1)))))
+			         (od? (lambda (n) (if (= n 0) 'odd (ev? (- n 1))))))
+				(ev? 100))`))
+		assert.Equal(t, KindUnboundVariable, evalError(t, `(letrec ((a 1) (b a)) b)`).Kind)
+	})
+
+	t.Run("letrec*", func(t *testing.T) {
+		assert.Equal(t, 2, mustEval(t, `(letrec* ((a 1) (b (+ a 1))) b)`))
+	})
+
+	t.Run("named let", func(t *testing.T) {
+		assert.Equal(t, 120, mustEval(t, `
+			(let fact ((n 5) (acc 1))
+				(if (= n 0)
+					acc
+					(fact (- n 1) (* acc n))))`))
+	})
+
+	t.Run("named let loops in constant stack", func(t *testing.T) {
+		assert.Equal(t, 100000, mustEval(t, `
+			(let loop ((i 0))
+				(if (= i 100000)
+					i
+					(loop (+ i 1))))`))
+	})
+
+	t.Run("let-values", func(t *testing.T) {
+		assert.Equal(t, sexpr.List(1, 2, sexpr.List(3, 4), 5), mustEval(t, `
+			(let-values (((a b . rest) (values 1 2 3 4))
+			             ((c) (values 5)))
+				(list a b rest c))`))
+		err := evalError(t, `(let-values (((a b) (values 1))) a)`)
+		assert.Equal(t, KindArity, err.Kind)
+		assert.Equal(t, "Wrong number of values received by let-values, expected 2, got: 1", err.Error())
+		assert.Equal(t, KindArity, evalError(t, `(let-values (((a #!optional b) (values 1 2 3))) a)`).Kind)
+	})
+
+	t.Run("continuation of let-values init is reentered", func(t *testing.T) {
+		assert.Equal(t, "((3 10 20) 3)", sexpr.Print(mustEval(t, `
+			(let ((k #f) (n 0))
+				(let ((result (let-values (((a) (call/cc (lambda (c) (set! k c) 1)))
+				                           ((b c) (values 10 20)))
+				                (list a b c))))
+					(set! n (+ n 1))
+					(if (< n 3) (k (+ n 1)) (list result n))))`)))
+	})
+
+	t.Run("call-with-values", func(t *testing.T) {
+		assert.Equal(t, 3, mustEval(t, `(call-with-values (lambda () (values 1 2)) +)`))
+		assert.Equal(t, sexpr.List(1), mustEval(t, `(call-with-values (lambda () 1) list)`))
+		assert.Equal(t, "1 (2)", sexpr.Print(mustEval(t, `(values 1 '(2))`)))
+	})
+
+	t.Run("ill-formed let", func(t *testing.T) {
+		assert.Equal(t, KindSyntax, evalError(t, `(let ((a)) a)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(let ((a 1) (a 2)) a)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(let ((a 1)))`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(let loop ((1 a)) a)`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(let-values (((a) 1) ((b a) (values 2 3))) a)`).Kind)
+	})
+}
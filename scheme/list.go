@@ -0,0 +1,158 @@
+package scheme
+
+import (
+	"reflect"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func addListBuiltins(env *Environment) {
+	AddFuncToEnv(env, "cons", consBuiltin)
+	AddFuncToEnv(env, "car", carBuiltin)
+	AddFuncToEnv(env, "cdr", cdrBuiltin)
+	AddFuncToEnv(env, "set-car!", setCarBuiltin)
+	AddFuncToEnv(env, "set-cdr!", setCdrBuiltin)
+	AddFuncToEnv(env, "list", listBuiltin)
+	AddFuncToEnv(env, "pair?", isPairBuiltin)
+	AddFuncToEnv(env, "null?", isNullBuiltin)
+	AddFuncToEnv(env, "list?", isListBuiltin)
+	AddFuncToEnv(env, "eq?", isEqBuiltin)
+	AddFuncToEnv(env, "eqv?", isEqBuiltin)
+	AddFuncToEnv(env, "equal?", isEqualBuiltin)
+	AddFuncToEnv(env, "assq", assocBuiltin("assq", isEqv))
+	AddFuncToEnv(env, "assv", assocBuiltin("assv", isEqv))
+	AddFuncToEnv(env, "assoc", assocBuiltin("assoc", sexpr.Equal))
+}
+
+// isEqv reports whether a and b are the same object. Pairs and procedures are
+// compared by identity, numbers by value and exactness, other values by
+// content.
+func isEqv(a sexpr.Expr, b sexpr.Expr) bool {
+	if sexpr.IsNumber(a) {
+		return sexpr.Equal(a, b)
+	}
+	typ := reflect.TypeOf(a)
+	if typ != reflect.TypeOf(b) || typ == nil || !typ.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+func consBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("cons", 2, args); err != nil {
+		return nil, err
+	}
+	return sexpr.Cons(args[0], args[1]), nil
+}
+
+func carBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("car", 1, args); err != nil {
+		return nil, err
+	}
+	pair, ok := args[0].(*sexpr.Pair)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "car")
+	}
+	return pair.Car, nil
+}
+
+func cdrBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("cdr", 1, args); err != nil {
+		return nil, err
+	}
+	pair, ok := args[0].(*sexpr.Pair)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "cdr")
+	}
+	return pair.Cdr, nil
+}
+
+func setCarBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("set-car!", 2, args); err != nil {
+		return nil, err
+	}
+	pair, ok := args[0].(*sexpr.Pair)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "set-car!")
+	}
+	pair.Car = args[1]
+	return nil, nil
+}
+
+func setCdrBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("set-cdr!", 2, args); err != nil {
+		return nil, err
+	}
+	pair, ok := args[0].(*sexpr.Pair)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "set-cdr!")
+	}
+	pair.Cdr = args[1]
+	return nil, nil
+}
+
+func listBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	return sexpr.List(args...), nil
+}
+
+func isPairBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("pair?", 1, args); err != nil {
+		return nil, err
+	}
+	_, ok := args[0].(*sexpr.Pair)
+	return ok, nil
+}
+
+func isNullBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("null?", 1, args); err != nil {
+		return nil, err
+	}
+	return args[0] == sexpr.Nil, nil
+}
+
+func isListBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("list?", 1, args); err != nil {
+		return nil, err
+	}
+	return sexpr.IsList(args[0]), nil
+}
+
+func isEqBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("eq?", 2, args); err != nil {
+		return nil, err
+	}
+	return isEqv(args[0], args[1]), nil
+}
+
+func isEqualBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("equal?", 2, args); err != nil {
+		return nil, err
+	}
+	return sexpr.Equal(args[0], args[1]), nil
+}
+
+// assocBuiltin makes procedure which finds the first pair in association list
+// which car is equal to key, #f is returned if there is no such pair.
+func assocBuiltin(name string, equal func(a, b sexpr.Expr) bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArity(name, 2, args); err != nil {
+			return nil, err
+		}
+		key, alist := args[0], args[1]
+		for alist != sexpr.Nil {
+			pair, ok := alist.(*sexpr.Pair)
+			if !ok {
+				return nil, errWrongType(args[1], 1, name)
+			}
+			entry, ok := pair.Car.(*sexpr.Pair)
+			if !ok {
+				return nil, errWrongType(args[1], 1, name)
+			}
+			if equal(key, entry.Car) {
+				return entry, nil
+			}
+			alist = pair.Cdr
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,92 @@
+package scheme
+
+import (
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// procedureMacro makes unhygienic macro, procedure receives unevaluated
+// arguments of the form and returns its expansion.
+func procedureMacro(name sexpr.Symbol, procedure sexpr.Expr) *Macro {
+	return &Macro{
+		Name: name,
+		Transformer: func(form sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+			arguments, ok := sexpr.ToSlice(form.(*sexpr.Pair).Cdr)
+			if !ok {
+				return nil, errIllFormed(form)
+			}
+			return applyProcedure(procedure, arguments, env)
+		},
+	}
+}
+
+// evalDefineMacro evaluates (define-macro (name . formals) body...),
+// (define-macro name procedure) and (defmacro name formals body...).
+func evalDefineMacro(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if syntaxKeyword(list[0], env) != "defmacro" {
+		return evalDefinition(form, list, env, func(id sexpr.Expr, procedure sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+			name, err := defineMacro(form, id, procedure, env)
+			return name, nil, err
+		})
+	}
+	if len(list) < 4 {
+		return nil, nil, errIllFormed(form)
+	}
+	lambda, err := makeLambda(list[2], list[3:], env)
+	if err != nil {
+		return nil, nil, withExpr(err, form)
+	}
+	name, err := defineMacro(form, list[1], lambda, env)
+	return name, nil, err
+}
+
+// defineMacro binds id to macro which expands by procedure.
+func defineMacro(form sexpr.Expr, id sexpr.Expr, procedure sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	name, ok := bindingName(id)
+	if !ok {
+		return nil, errIllFormed(form)
+	}
+	switch procedure := procedure.(type) {
+	case *Lambda:
+		procedure.Name = baseName(id)
+	case *Primitive:
+	default:
+		return nil, withExpr(errWrongType(procedure, 1, "define-macro"), form)
+	}
+	env.Define(name, procedureMacro(baseName(id), procedure))
+	return baseName(id), nil
+}
+
+func addMacroBuiltins(env *Environment) {
+	AddFuncToEnv(env, "macroexpand-1", macroexpandBuiltin("macroexpand-1", true))
+	AddFuncToEnv(env, "macroexpand", macroexpandBuiltin("macroexpand", false))
+}
+
+// macroexpandBuiltin expands macro use once or until the form is not a macro
+// use anymore. Nested forms are not expanded.
+func macroexpandBuiltin(name string, once bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArity(name, 1, args); err != nil {
+			return nil, err
+		}
+		form := args[0]
+		for {
+			pair, ok := form.(*sexpr.Pair)
+			if !ok || !isIdentifier(pair.Car) {
+				return form, nil
+			}
+			value, _ := lookupIdentifier(pair.Car, env)
+			macro, ok := value.(*Macro)
+			if !ok {
+				return form, nil
+			}
+			expansion, err := macro.Transformer(form, env)
+			if err != nil {
+				return nil, err
+			}
+			form = stripSyntax(expansion)
+			if once {
+				return form, nil
+			}
+		}
+	}
+}
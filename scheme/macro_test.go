@@ -0,0 +1,96 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestDefineMacro(t *testing.T) {
+	t.Run("define-macro receives raw forms", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-macro (my-unless condition . body)
+				(list 'if condition #f (cons 'begin body)))
+		`)
+
+		// assert
+		assert.Equal(t, 2, mustEval(t, `(my-unless #f 1 2)`))
+		assert.Equal(t, false, mustEval(t, `(my-unless #t (car '()))`))
+	})
+
+	t.Run("define-macro with procedure", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-macro swap-args
+				(lambda (form) (list (car form) (car (cdr (cdr form))) (car (cdr form)))))
+		`)
+
+		// assert
+		assert.Equal(t, 2, mustEval(t, `(swap-args (- 1 3 5))`))
+		assert.Equal(t, KindWrongType, evalError(t, `(define-macro not-a-macro 42)`).Kind)
+	})
+
+	t.Run("defmacro", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(defmacro inc! (place #!optional (delta 1))
+				(list 'set! place (list '+ place delta)))
+		`)
+
+		// act
+		result := mustEval(t, `
+			(let ((counter 10))
+				(inc! counter)
+				(inc! counter 5)
+				counter)`)
+
+		// assert
+		assert.Equal(t, 16, result)
+	})
+
+	t.Run("define-macro is not hygienic", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(defmacro with-it (value . body)
+				(cons 'let (cons (list (list 'it value)) body)))
+		`)
+
+		// assert
+		assert.Equal(t, 42, mustEval(t, `(with-it 21 (* it 2))`))
+	})
+
+	t.Run("macroexpand", func(t *testing.T) {
+		// arrange
+		mustEval(t, `(defmacro twice (x) (list 'begin x x))`)
+		mustEval(t, `(defmacro twice-twice (x) (list 'twice (list 'twice x)))`)
+
+		// assert
+		assert.Equal(t,
+			"(twice (twice (display 1)))",
+			sexpr.Print(mustEval(t, `(macroexpand-1 '(twice-twice (display 1)))`)),
+		)
+		assert.Equal(t,
+			"(begin (twice (display 1)) (twice (display 1)))",
+			sexpr.Print(mustEval(t, `(macroexpand '(twice-twice (display 1)))`)),
+		)
+		assert.Equal(t, sexpr.List(1, 2), mustEval(t, `(macroexpand '(1 2))`))
+	})
+
+	t.Run("macroexpand syntax-rules", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax my-when
+				(syntax-rules ()
+					((_ c body ...) (if c (begin body ...) #f))))
+		`)
+
+		// assert
+		assert.Equal(t,
+			"(if x (begin 1 2) #f)",
+			sexpr.Print(mustEval(t, `(macroexpand '(my-when x 1 2))`)),
+		)
+	})
+}
@@ -1,122 +1,76 @@
 package scheme
 
 import (
-	"fmt"
+	"strconv"
 
 	"github.com/adzeitor/goscheme/sexpr"
 )
 
-func addBultin(env Environment) {
-	env.Global["+"] = Builtin(plusBuiltin)
-	env.Global["-"] = Builtin(minusBuiltin)
-	env.Global["*"] = Builtin(multBuiltin)
-	env.Global[">"] = Builtin(greaterBuiltin)
-	env.Global["<"] = Builtin(lessBuiltin)
-	env.Global["car"] = Builtin(carBuiltin)
-	env.Global["cdr"] = Builtin(cdrBuiltin)
-	env.Global["list?"] = Builtin(isListBuiltin)
-	env.Global["symbol?"] = Builtin(isSymbolBuiltin)
-	env.Global["do"] = Builtin(doBuiltin)
-	env.Global["set!"] = Builtin(setBuiltin)
+func addBultin(env *Environment) {
+	AddFuncToEnv(env, "symbol?", predicateBuiltin("symbol?", func(e sexpr.Expr) bool {
+		_, ok := e.(sexpr.Symbol)
+		return ok
+	}))
+	AddFuncToEnv(env, "default-object?", isDefaultObjectBuiltin)
+	AddFuncToEnv(env, "not", predicateBuiltin("not", func(e sexpr.Expr) bool {
+		return !isTrue(e)
+	}))
+	AddFuncToEnv(env, "boolean?", predicateBuiltin("boolean?", func(e sexpr.Expr) bool {
+		_, ok := e.(bool)
+		return ok
+	}))
+	addListBuiltins(env)
+	addNumberBuiltins(env)
+	addCharBuiltins(env)
+	addVectorBuiltins(env)
+	addValuesBuiltins(env)
+	addMacroBuiltins(env)
+	addContinuationBuiltins(env)
+	addExceptionBuiltins(env)
+	addOutputBuiltins(env)
 }
 
-func plusBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	n1 := eval(args[0], env)
-	n2 := eval(args[1], env)
-	return n1.(int) + n2.(int)
-}
-
-func minusBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	n1 := eval(args[0], env)
-	n2 := eval(args[1], env)
-	return n1.(int) - n2.(int)
-}
-
-func multBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	n1 := eval(args[0], env)
-	n2 := eval(args[1], env)
-	return n1.(int) * n2.(int)
-}
-
-func greaterBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	n1 := eval(args[0], env)
-	n2 := eval(args[1], env)
-	return n1.(int) > n2.(int)
-}
-
-func lessBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	n1 := eval(args[0], env)
-	n2 := eval(args[1], env)
-	return n1.(int) < n2.(int)
-}
-
-func carBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	arg := eval(args[0], env)
-	list, ok := arg.([]sexpr.Expr)
-	if !ok {
-		panic(
-			fmt.Sprintf(
-				"The object %v, passed as the first argument to car, is not the correct type.",
-				sexpr.Print(arg),
-			))
+// checkArity ensures that builtin procedure is called with count arguments.
+func checkArity(name string, count int, args []sexpr.Expr) error {
+	if len(args) != count {
+		return errArity(name, strconv.Itoa(count), len(args))
 	}
-	return list[0]
+	return nil
 }
 
-func cdrBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	arg := eval(args[0], env)
-	list, ok := arg.([]sexpr.Expr)
-	if !ok {
-		panic(
-			fmt.Sprintf(
-				"The object %v, passed as the first argument to cdr, is not the correct type.",
-				sexpr.Print(arg),
-			))
+// checkMinArity ensures that builtin procedure is called with at least min
+// arguments.
+func checkMinArity(name string, min int, args []sexpr.Expr) error {
+	if len(args) < min {
+		return errArity(name, "at least "+strconv.Itoa(min), len(args))
 	}
-	return list[1:]
-}
-
-func isListBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	arg := eval(args[0], env)
-	_, ok := arg.([]sexpr.Expr)
-	return ok
+	return nil
 }
 
-func isSymbolBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	arg := eval(args[0], env)
-	_, ok := arg.(sexpr.Symbol)
-	return ok
+// checkArityRange ensures that builtin procedure with optional arguments is
+// called with from min to max arguments.
+func checkArityRange(name string, min int, max int, args []sexpr.Expr) error {
+	if len(args) < min || len(args) > max {
+		return errArity(name, strconv.Itoa(min)+" to "+strconv.Itoa(max), len(args))
+	}
+	return nil
 }
 
-func doBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	result := sexpr.Expr(nil)
-	for _, arg := range args {
-		result = eval(arg, env)
+func isDefaultObjectBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("default-object?", 1, args); err != nil {
+		return nil, err
 	}
-	return result
+	_, ok := args[0].(DefaultObject)
+	return ok, nil
 }
 
-func setBuiltin(args []sexpr.Expr, env Environment) sexpr.Expr {
-	name := args[0].(sexpr.Symbol)
-	value := eval(args[1], env)
-	// global?
-	if _, ok := env.Global[name]; ok {
-		env.Global[name] = value
-		return nil
-	}
-	// local?
-	env.Local[name] = value
-	return nil
+// AddFuncToEnv defines procedure f, which receives evaluated arguments.
+func AddFuncToEnv(env *Environment, name string, f Procedure) {
+	env.Define(sexpr.Symbol(name), &Primitive{Name: sexpr.Symbol(name), Procedure: f})
 }
 
-// FIXME: maybe change to WithEvalArguments
-// or just introduce defmacro
-func AddFuncToEnv(env Environment, name string, f Builtin) {
-	env.Global[sexpr.Symbol(name)] = Builtin(func(args []sexpr.Expr, env Environment) sexpr.Expr {
-		evaledArgs := make([]sexpr.Expr, 0, len(args))
-		for _, arg := range args {
-			evaledArgs = append(evaledArgs, eval(arg, env))
-		}
-		return f(evaledArgs, env)
-	})
+// addControlToEnv defines procedure f, which receives the continuation of its
+// call.
+func addControlToEnv(env *Environment, name string, f controlProcedure) {
+	env.Define(sexpr.Symbol(name), &Primitive{Name: sexpr.Symbol(name), control: f})
 }
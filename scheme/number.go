@@ -0,0 +1,898 @@
+package scheme
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// numberKind is a level of the numeric tower, operations on numbers of
+// different kinds convert both to the higher kind.
+type numberKind int
+
+const (
+	notNumber numberKind = iota
+	fixnum
+	bignum
+	ratnum
+	flonum
+)
+
+func kindOf(n sexpr.Expr) numberKind {
+	switch n.(type) {
+	case int:
+		return fixnum
+	case *big.Int:
+		return bignum
+	case *big.Rat:
+		return ratnum
+	case float64:
+		return flonum
+	}
+	return notNumber
+}
+
+func isExact(n sexpr.Expr) bool {
+	kind := kindOf(n)
+	return kind == fixnum || kind == bignum || kind == ratnum
+}
+
+func isExactInteger(n sexpr.Expr) bool {
+	kind := kindOf(n)
+	return kind == fixnum || kind == bignum
+}
+
+func isInteger(n sexpr.Expr) bool {
+	if f, ok := n.(float64); ok {
+		return !math.IsInf(f, 0) && f == math.Trunc(f)
+	}
+	return isExactInteger(n)
+}
+
+func toBigInt(n sexpr.Expr) *big.Int {
+	switch value := n.(type) {
+	case int:
+		return big.NewInt(int64(value))
+	case *big.Int:
+		return value
+	}
+	panic("toBigInt: not an exact integer")
+}
+
+func toBigRat(n sexpr.Expr) *big.Rat {
+	switch value := n.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(value))
+	case *big.Int:
+		return new(big.Rat).SetInt(value)
+	case *big.Rat:
+		return value
+	}
+	panic("toBigRat: not an exact number")
+}
+
+func toFloat(n sexpr.Expr) float64 {
+	switch value := n.(type) {
+	case int:
+		return float64(value)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(value).Float64()
+		return f
+	case *big.Rat:
+		f, _ := value.Float64()
+		return f
+	case float64:
+		return value
+	}
+	panic("toFloat: not a number")
+}
+
+func commonKind(a sexpr.Expr, b sexpr.Expr) numberKind {
+	kind := kindOf(a)
+	if other := kindOf(b); other > kind {
+		kind = other
+	}
+	return kind
+}
+
+// arithmetic is a set of implementations of binary operation for every kind
+// of numbers. Fixnum implementation reports false on overflow, then the
+// operation is repeated on bignums.
+type arithmetic struct {
+	fixnum func(a, b int) (int, bool)
+	bignum func(z, a, b *big.Int) *big.Int
+	ratnum func(z, a, b *big.Rat) *big.Rat
+	flonum func(a, b float64) float64
+}
+
+func (op arithmetic) apply(a sexpr.Expr, b sexpr.Expr) sexpr.Expr {
+	switch commonKind(a, b) {
+	case fixnum:
+		if result, ok := op.fixnum(a.(int), b.(int)); ok {
+			return result
+		}
+		fallthrough
+	case bignum:
+		return sexpr.Integer(op.bignum(new(big.Int), toBigInt(a), toBigInt(b)))
+	case ratnum:
+		return sexpr.Rational(op.ratnum(new(big.Rat), toBigRat(a), toBigRat(b)))
+	default:
+		return op.flonum(toFloat(a), toFloat(b))
+	}
+}
+
+var addition = arithmetic{
+	fixnum: func(a, b int) (int, bool) {
+		c := a + b
+		return c, (c > a) == (b > 0)
+	},
+	bignum: (*big.Int).Add,
+	ratnum: (*big.Rat).Add,
+	flonum: func(a, b float64) float64 { return a + b },
+}
+
+var subtraction = arithmetic{
+	fixnum: func(a, b int) (int, bool) {
+		c := a - b
+		return c, (c < a) == (b > 0)
+	},
+	bignum: (*big.Int).Sub,
+	ratnum: (*big.Rat).Sub,
+	flonum: func(a, b float64) float64 { return a - b },
+}
+
+var multiplication = arithmetic{
+	fixnum: func(a, b int) (int, bool) {
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+		c := a * b
+		return c, c/b == a && !(a == -1 && b == math.MinInt) && !(b == -1 && a == math.MinInt)
+	},
+	bignum: (*big.Int).Mul,
+	ratnum: (*big.Rat).Mul,
+	flonum: func(a, b float64) float64 { return a * b },
+}
+
+func add(a sexpr.Expr, b sexpr.Expr) sexpr.Expr {
+	return addition.apply(a, b)
+}
+
+func sub(a sexpr.Expr, b sexpr.Expr) sexpr.Expr {
+	return subtraction.apply(a, b)
+}
+
+func mul(a sexpr.Expr, b sexpr.Expr) sexpr.Expr {
+	return multiplication.apply(a, b)
+}
+
+func div(a sexpr.Expr, b sexpr.Expr) (sexpr.Expr, error) {
+	if commonKind(a, b) == flonum {
+		return toFloat(a) / toFloat(b), nil
+	}
+	if sign(b) == 0 {
+		return nil, errDivideByZero("/")
+	}
+	return sexpr.Rational(new(big.Rat).Quo(toBigRat(a), toBigRat(b))), nil
+}
+
+func sign(n sexpr.Expr) int {
+	switch value := n.(type) {
+	case int:
+		switch {
+		case value > 0:
+			return 1
+		case value < 0:
+			return -1
+		}
+		return 0
+	case *big.Int:
+		return value.Sign()
+	case *big.Rat:
+		return value.Sign()
+	case float64:
+		switch {
+		case value > 0:
+			return 1
+		case value < 0:
+			return -1
+		}
+	}
+	return 0
+}
+
+// compareNumbers returns -1, 0 or 1 like big.Int.Cmp, ok is false if numbers
+// are not comparable (one of them is NaN).
+func compareNumbers(a sexpr.Expr, b sexpr.Expr) (result int, ok bool) {
+	switch commonKind(a, b) {
+	case fixnum:
+		x, y := a.(int), b.(int)
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case bignum:
+		return toBigInt(a).Cmp(toBigInt(b)), true
+	case ratnum:
+		return toBigRat(a).Cmp(toBigRat(b)), true
+	}
+	x, y := toFloat(a), toFloat(b)
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return 0, false
+	}
+	// exact numbers are compared exactly with finite floats
+	if !math.IsInf(x, 0) && !math.IsInf(y, 0) && (isExact(a) || isExact(b)) {
+		return toExactRat(a).Cmp(toExactRat(b)), true
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
+
+func toExactRat(n sexpr.Expr) *big.Rat {
+	if f, ok := n.(float64); ok {
+		return new(big.Rat).SetFloat64(f)
+	}
+	return toBigRat(n)
+}
+
+func toExact(n sexpr.Expr, procedure string) (sexpr.Expr, error) {
+	f, ok := n.(float64)
+	if !ok {
+		return n, nil
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, errBadRange(n, 0, procedure)
+	}
+	return sexpr.Rational(new(big.Rat).SetFloat64(f)), nil
+}
+
+// integerDivision implements quotient, remainder and modulo families.
+// If floor is set the quotient is rounded toward negative infinity,
+// otherwise toward zero.
+func integerDivision(procedure string, a sexpr.Expr, b sexpr.Expr, floor bool) (quotient sexpr.Expr, remainder sexpr.Expr, err error) {
+	if !isInteger(a) {
+		return nil, nil, errWrongType(a, 0, procedure)
+	}
+	if !isInteger(b) {
+		return nil, nil, errWrongType(b, 1, procedure)
+	}
+	if sign(b) == 0 {
+		return nil, nil, errDivideByZero(procedure)
+	}
+
+	switch commonKind(a, b) {
+	case flonum:
+		x, y := toFloat(a), toFloat(b)
+		q, r := math.Trunc(x/y), math.Mod(x, y)
+		if floor && r != 0 && (r < 0) != (y < 0) {
+			q, r = q-1, r+y
+		}
+		return q, r, nil
+	case fixnum:
+		x, y := a.(int), b.(int)
+		if !(x == math.MinInt && y == -1) {
+			q, r := x/y, x%y
+			if floor && r != 0 && (r < 0) != (y < 0) {
+				q, r = q-1, r+y
+			}
+			return q, r, nil
+		}
+	}
+
+	x, y := toBigInt(a), toBigInt(b)
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if floor && r.Sign() != 0 && r.Sign() != y.Sign() {
+		q.Sub(q, big.NewInt(1))
+		r.Add(r, y)
+	}
+	return sexpr.Integer(q), sexpr.Integer(r), nil
+}
+
+type rounding int
+
+const (
+	roundFloor rounding = iota
+	roundCeiling
+	roundTruncate
+	roundToEven
+)
+
+func roundNumber(n sexpr.Expr, mode rounding) sexpr.Expr {
+	switch value := n.(type) {
+	case float64:
+		switch mode {
+		case roundFloor:
+			return math.Floor(value)
+		case roundCeiling:
+			return math.Ceil(value)
+		case roundTruncate:
+			return math.Trunc(value)
+		default:
+			return math.RoundToEven(value)
+		}
+	case *big.Rat:
+		num, den := value.Num(), value.Denom()
+		// big.Int.Div is Euclidean division, the denominator is positive so it
+		// rounds toward negative infinity.
+		floor := new(big.Int).Div(num, den)
+		switch mode {
+		case roundFloor:
+		case roundCeiling:
+			floor.Add(floor, big.NewInt(1))
+		case roundTruncate:
+			if num.Sign() < 0 {
+				floor.Add(floor, big.NewInt(1))
+			}
+		default:
+			// compare fraction part with one half: 2*(num - floor*den) vs den
+			fraction := new(big.Int).Sub(num, new(big.Int).Mul(floor, den))
+			switch fraction.Lsh(fraction, 1).Cmp(den) {
+			case 1:
+				floor.Add(floor, big.NewInt(1))
+			case 0:
+				if floor.Bit(0) == 1 {
+					floor.Add(floor, big.NewInt(1))
+				}
+			}
+		}
+		return sexpr.Integer(floor)
+	}
+	return n
+}
+
+// exactSqrt returns square root of non-negative exact integer if it is exact.
+func exactSqrt(n *big.Int) (*big.Int, bool) {
+	root := new(big.Int).Sqrt(n)
+	return root, new(big.Int).Mul(root, root).Cmp(n) == 0
+}
+
+func sqrt(n sexpr.Expr) (sexpr.Expr, error) {
+	if sign(n) < 0 {
+		return nil, errBadRange(n, 0, "sqrt")
+	}
+	switch kindOf(n) {
+	case fixnum, bignum:
+		if root, ok := exactSqrt(toBigInt(n)); ok {
+			return sexpr.Integer(root), nil
+		}
+	case ratnum:
+		r := n.(*big.Rat)
+		num, numOk := exactSqrt(r.Num())
+		den, denOk := exactSqrt(r.Denom())
+		if numOk && denOk {
+			return sexpr.Rational(new(big.Rat).SetFrac(num, den)), nil
+		}
+	}
+	return math.Sqrt(toFloat(n)), nil
+}
+
+// maxExptBits limits size of exact result of expt, so it can not exhaust
+// memory in a single call.
+const maxExptBits = 1 << 26
+
+func expt(base sexpr.Expr, power sexpr.Expr) (sexpr.Expr, error) {
+	if !isExactInteger(power) || !isExact(base) {
+		return math.Pow(toFloat(base), toFloat(power)), nil
+	}
+	exponent := new(big.Int).Abs(toBigInt(power))
+	r := toBigRat(base)
+	// the result has at least so many bits per multiplication, bases 0, 1
+	// and -1 do not grow
+	bits := r.Num().BitLen()
+	if r.Denom().BitLen() > bits {
+		bits = r.Denom().BitLen()
+	}
+	bits--
+	if bits > 0 && (!exponent.IsInt64() || exponent.Int64() > maxExptBits/int64(bits)) {
+		return nil, errBadRange(power, 1, "expt")
+	}
+	num := new(big.Int).Exp(r.Num(), exponent, nil)
+	den := new(big.Int).Exp(r.Denom(), exponent, nil)
+	if sign(power) < 0 {
+		if num.Sign() == 0 {
+			return nil, errDivideByZero("expt")
+		}
+		num, den = den, num
+	}
+	return sexpr.Rational(new(big.Rat).SetFrac(num, den)), nil
+}
+
+func gcd(a *big.Int, b *big.Int) *big.Int {
+	return new(big.Int).GCD(nil, nil, new(big.Int).Abs(a), new(big.Int).Abs(b))
+}
+
+func addNumberBuiltins(env *Environment) {
+	AddFuncToEnv(env, "+", plusBuiltin)
+	AddFuncToEnv(env, "-", minusBuiltin)
+	AddFuncToEnv(env, "*", multBuiltin)
+	AddFuncToEnv(env, "/", divBuiltin)
+	AddFuncToEnv(env, "=", compareBuiltin("=", func(c int) bool { return c == 0 }))
+	AddFuncToEnv(env, "<", compareBuiltin("integer-less?", func(c int) bool { return c < 0 }))
+	AddFuncToEnv(env, ">", compareBuiltin("integer-greater?", func(c int) bool { return c > 0 }))
+	AddFuncToEnv(env, "<=", compareBuiltin("integer-less-or-equal?", func(c int) bool { return c <= 0 }))
+	AddFuncToEnv(env, ">=", compareBuiltin("integer-greater-or-equal?", func(c int) bool { return c >= 0 }))
+	AddFuncToEnv(env, "abs", absBuiltin)
+	AddFuncToEnv(env, "min", extremumBuiltin("min", func(c int) bool { return c < 0 }))
+	AddFuncToEnv(env, "max", extremumBuiltin("max", func(c int) bool { return c > 0 }))
+	AddFuncToEnv(env, "quotient", integerDivisionBuiltin("quotient", false, false))
+	AddFuncToEnv(env, "remainder", integerDivisionBuiltin("remainder", false, true))
+	AddFuncToEnv(env, "modulo", integerDivisionBuiltin("modulo", true, true))
+	AddFuncToEnv(env, "truncate-quotient", integerDivisionBuiltin("truncate-quotient", false, false))
+	AddFuncToEnv(env, "truncate-remainder", integerDivisionBuiltin("truncate-remainder", false, true))
+	AddFuncToEnv(env, "floor-quotient", integerDivisionBuiltin("floor-quotient", true, false))
+	AddFuncToEnv(env, "floor-remainder", integerDivisionBuiltin("floor-remainder", true, true))
+	AddFuncToEnv(env, "gcd", gcdBuiltin)
+	AddFuncToEnv(env, "lcm", lcmBuiltin)
+	AddFuncToEnv(env, "numerator", numeratorBuiltin("numerator"))
+	AddFuncToEnv(env, "denominator", numeratorBuiltin("denominator"))
+
+	AddFuncToEnv(env, "floor", roundBuiltin("floor", roundFloor))
+	AddFuncToEnv(env, "ceiling", roundBuiltin("ceiling", roundCeiling))
+	AddFuncToEnv(env, "truncate", roundBuiltin("truncate", roundTruncate))
+	AddFuncToEnv(env, "round", roundBuiltin("round", roundToEven))
+
+	AddFuncToEnv(env, "exact", exactBuiltin("exact"))
+	AddFuncToEnv(env, "inexact->exact", exactBuiltin("inexact->exact"))
+	AddFuncToEnv(env, "inexact", inexactBuiltin("inexact"))
+	AddFuncToEnv(env, "exact->inexact", inexactBuiltin("exact->inexact"))
+
+	AddFuncToEnv(env, "square", squareBuiltin)
+	AddFuncToEnv(env, "sqrt", sqrtBuiltin)
+	AddFuncToEnv(env, "expt", exptBuiltin)
+	AddFuncToEnv(env, "exp", floatBuiltin("exp", math.Exp))
+	AddFuncToEnv(env, "sin", floatBuiltin("sin", math.Sin))
+	AddFuncToEnv(env, "cos", floatBuiltin("cos", math.Cos))
+	AddFuncToEnv(env, "tan", floatBuiltin("tan", math.Tan))
+	AddFuncToEnv(env, "asin", floatBuiltin("asin", math.Asin))
+	AddFuncToEnv(env, "acos", floatBuiltin("acos", math.Acos))
+	AddFuncToEnv(env, "log", logBuiltin)
+	AddFuncToEnv(env, "atan", atanBuiltin)
+
+	AddFuncToEnv(env, "number->string", numberToStringBuiltin)
+	AddFuncToEnv(env, "string->number", stringToNumberBuiltin)
+
+	AddFuncToEnv(env, "number?", predicateBuiltin("number?", sexpr.IsNumber))
+	AddFuncToEnv(env, "complex?", predicateBuiltin("complex?", sexpr.IsNumber))
+	AddFuncToEnv(env, "real?", predicateBuiltin("real?", sexpr.IsNumber))
+	AddFuncToEnv(env, "rational?", predicateBuiltin("rational?", func(n sexpr.Expr) bool {
+		if f, ok := n.(float64); ok {
+			return !math.IsInf(f, 0) && !math.IsNaN(f)
+		}
+		return isExact(n)
+	}))
+	AddFuncToEnv(env, "integer?", predicateBuiltin("integer?", isInteger))
+	AddFuncToEnv(env, "exact-integer?", predicateBuiltin("exact-integer?", isExactInteger))
+	AddFuncToEnv(env, "exact?", numberPredicateBuiltin("exact?", isExact))
+	AddFuncToEnv(env, "inexact?", numberPredicateBuiltin("inexact?", func(n sexpr.Expr) bool {
+		return kindOf(n) == flonum
+	}))
+	AddFuncToEnv(env, "nan?", numberPredicateBuiltin("nan?", func(n sexpr.Expr) bool {
+		f, ok := n.(float64)
+		return ok && math.IsNaN(f)
+	}))
+	AddFuncToEnv(env, "infinite?", numberPredicateBuiltin("infinite?", func(n sexpr.Expr) bool {
+		f, ok := n.(float64)
+		return ok && math.IsInf(f, 0)
+	}))
+	AddFuncToEnv(env, "finite?", numberPredicateBuiltin("finite?", func(n sexpr.Expr) bool {
+		f, ok := n.(float64)
+		return !ok || !math.IsInf(f, 0) && !math.IsNaN(f)
+	}))
+	AddFuncToEnv(env, "zero?", numberPredicateBuiltin("zero?", func(n sexpr.Expr) bool {
+		return sign(n) == 0 && !isNaN(n)
+	}))
+	AddFuncToEnv(env, "positive?", numberPredicateBuiltin("positive?", func(n sexpr.Expr) bool {
+		return sign(n) > 0
+	}))
+	AddFuncToEnv(env, "negative?", numberPredicateBuiltin("negative?", func(n sexpr.Expr) bool {
+		return sign(n) < 0
+	}))
+	AddFuncToEnv(env, "odd?", integerPredicateBuiltin("odd?", func(remainder sexpr.Expr) bool {
+		return sign(remainder) != 0
+	}))
+	AddFuncToEnv(env, "even?", integerPredicateBuiltin("even?", func(remainder sexpr.Expr) bool {
+		return sign(remainder) == 0
+	}))
+}
+
+func isNaN(n sexpr.Expr) bool {
+	f, ok := n.(float64)
+	return ok && math.IsNaN(f)
+}
+
+// checkNumbers ensures that all arguments of procedure are numbers.
+func checkNumbers(procedure string, args []sexpr.Expr) error {
+	for i, arg := range args {
+		if !sexpr.IsNumber(arg) {
+			return errWrongType(arg, i, procedure)
+		}
+	}
+	return nil
+}
+
+// checkNumberArgs ensures that procedure is called with count numbers.
+func checkNumberArgs(procedure string, count int, args []sexpr.Expr) error {
+	if err := checkArity(procedure, count, args); err != nil {
+		return err
+	}
+	return checkNumbers(procedure, args)
+}
+
+// fold applies binary operation to numbers from left to right starting from
+// initial value.
+func fold(procedure string, initial sexpr.Expr, args []sexpr.Expr, op func(a, b sexpr.Expr) sexpr.Expr) (sexpr.Expr, error) {
+	if err := checkNumbers(procedure, args); err != nil {
+		return nil, err
+	}
+	result := initial
+	for _, arg := range args {
+		result = op(result, arg)
+	}
+	return result, nil
+}
+
+func plusBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	return fold("integer-add", 0, args, add)
+}
+
+func multBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	return fold("integer-multiply", 1, args, mul)
+}
+
+// minusBuiltin subtracts the rest of arguments from the first one, the only
+// argument is negated.
+func minusBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkMinArity("-", 1, args); err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		return fold("integer-negate", 0, args, sub)
+	}
+	if err := checkNumbers("integer-subtract", args); err != nil {
+		return nil, err
+	}
+	return fold("integer-subtract", args[0], args[1:], sub)
+}
+
+// divBuiltin divides the first argument by the rest of them, the reciprocal
+// is returned for the only argument.
+func divBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkMinArity("/", 1, args); err != nil {
+		return nil, err
+	}
+	if err := checkNumbers("/", args); err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		return div(1, args[0])
+	}
+	result := args[0]
+	for _, arg := range args[1:] {
+		var err error
+		result, err = div(result, arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// compareBuiltin makes chained comparison, for example (< a b c) is true when
+// a < b and b < c. For compatibility = compares non-numbers structurally.
+func compareBuiltin(procedure string, holds func(comparison int) bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkMinArity(procedure, 1, args); err != nil {
+			return nil, err
+		}
+		if procedure == "=" && checkNumbers(procedure, args) != nil {
+			for _, arg := range args[1:] {
+				if !sexpr.Equal(args[0], arg) {
+					return false, nil
+				}
+			}
+			return true, nil
+		}
+		if err := checkNumbers(procedure, args); err != nil {
+			return nil, err
+		}
+		result := true
+		for i := 1; i < len(args); i++ {
+			comparison, ok := compareNumbers(args[i-1], args[i])
+			if !ok || !holds(comparison) {
+				// continue to validate the rest of arguments
+				result = false
+			}
+		}
+		return result, nil
+	}
+}
+
+func absBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkNumberArgs("abs", 1, args); err != nil {
+		return nil, err
+	}
+	if sign(args[0]) < 0 {
+		return sub(0, args[0]), nil
+	}
+	if f, ok := args[0].(float64); ok {
+		// turns -0.0 into 0.0
+		return math.Abs(f), nil
+	}
+	return args[0], nil
+}
+
+// extremumBuiltin makes min or max, the result is inexact if any argument is
+// inexact.
+func extremumBuiltin(procedure string, better func(comparison int) bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkMinArity(procedure, 1, args); err != nil {
+			return nil, err
+		}
+		if err := checkNumbers(procedure, args); err != nil {
+			return nil, err
+		}
+		result, inexact := args[0], false
+		for _, arg := range args {
+			if kindOf(arg) == flonum {
+				inexact = true
+			}
+			comparison, ok := compareNumbers(arg, result)
+			if !ok {
+				// NaN wins
+				result = math.NaN()
+			} else if better(comparison) {
+				result = arg
+			}
+		}
+		if inexact {
+			return toFloat(result), nil
+		}
+		return result, nil
+	}
+}
+
+func integerDivisionBuiltin(name string, floor bool, remainder bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArity(name, 2, args); err != nil {
+			return nil, err
+		}
+		q, r, err := integerDivision(name, args[0], args[1], floor)
+		if remainder {
+			return r, err
+		}
+		return q, err
+	}
+}
+
+func checkExactIntegers(procedure string, args []sexpr.Expr) error {
+	for i, arg := range args {
+		if !isExactInteger(arg) {
+			return errWrongType(arg, i, procedure)
+		}
+	}
+	return nil
+}
+
+func gcdBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkExactIntegers("gcd", args); err != nil {
+		return nil, err
+	}
+	result := new(big.Int)
+	for _, arg := range args {
+		result = gcd(result, toBigInt(arg))
+	}
+	return sexpr.Integer(result), nil
+}
+
+func lcmBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkExactIntegers("lcm", args); err != nil {
+		return nil, err
+	}
+	result := big.NewInt(1)
+	for _, arg := range args {
+		n := new(big.Int).Abs(toBigInt(arg))
+		if n.Sign() == 0 {
+			return 0, nil
+		}
+		result.Div(new(big.Int).Mul(result, n), gcd(result, n))
+	}
+	return sexpr.Integer(result), nil
+}
+
+func numeratorBuiltin(name string) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkNumberArgs(name, 1, args); err != nil {
+			return nil, err
+		}
+		exact, err := toExact(args[0], name)
+		if err != nil {
+			return nil, err
+		}
+		r := toBigRat(exact)
+		var result sexpr.Expr
+		if name == "numerator" {
+			result = sexpr.Integer(new(big.Int).Set(r.Num()))
+		} else {
+			result = sexpr.Integer(new(big.Int).Set(r.Denom()))
+		}
+		if !isExact(args[0]) {
+			return toFloat(result), nil
+		}
+		return result, nil
+	}
+}
+
+func roundBuiltin(name string, mode rounding) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkNumberArgs(name, 1, args); err != nil {
+			return nil, err
+		}
+		return roundNumber(args[0], mode), nil
+	}
+}
+
+func exactBuiltin(name string) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkNumberArgs(name, 1, args); err != nil {
+			return nil, err
+		}
+		return toExact(args[0], name)
+	}
+}
+
+func inexactBuiltin(name string) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkNumberArgs(name, 1, args); err != nil {
+			return nil, err
+		}
+		return toFloat(args[0]), nil
+	}
+}
+
+func squareBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkNumberArgs("square", 1, args); err != nil {
+		return nil, err
+	}
+	return mul(args[0], args[0]), nil
+}
+
+func sqrtBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkNumberArgs("sqrt", 1, args); err != nil {
+		return nil, err
+	}
+	return sqrt(args[0])
+}
+
+func exptBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkNumberArgs("expt", 2, args); err != nil {
+		return nil, err
+	}
+	return expt(args[0], args[1])
+}
+
+func floatBuiltin(name string, f func(float64) float64) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkNumberArgs(name, 1, args); err != nil {
+			return nil, err
+		}
+		return f(toFloat(args[0])), nil
+	}
+}
+
+func logBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("log", 1, 2, args); err != nil {
+		return nil, err
+	}
+	if err := checkNumbers("log", args); err != nil {
+		return nil, err
+	}
+	if len(args) == 2 {
+		return math.Log(toFloat(args[0])) / math.Log(toFloat(args[1])), nil
+	}
+	return math.Log(toFloat(args[0])), nil
+}
+
+func atanBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("atan", 1, 2, args); err != nil {
+		return nil, err
+	}
+	if err := checkNumbers("atan", args); err != nil {
+		return nil, err
+	}
+	if len(args) == 2 {
+		return math.Atan2(toFloat(args[0]), toFloat(args[1])), nil
+	}
+	return math.Atan(toFloat(args[0])), nil
+}
+
+// radixArgument returns optional radix argument with index position.
+func radixArgument(procedure string, args []sexpr.Expr, position int) (int, error) {
+	if len(args) <= position {
+		return 10, nil
+	}
+	radix := args[position]
+	switch radix {
+	case 2, 8, 10, 16:
+		return radix.(int), nil
+	}
+	return 0, errBadRange(radix, position, procedure)
+}
+
+func numberToStringBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("number->string", 1, 2, args); err != nil {
+		return nil, err
+	}
+	if err := checkNumbers("number->string", args[:1]); err != nil {
+		return nil, err
+	}
+	radix, err := radixArgument("number->string", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if radix != 10 && !isExact(args[0]) {
+		return nil, errBadRange(args[1], 1, "number->string")
+	}
+	return sexpr.FormatNumber(args[0], radix), nil
+}
+
+func stringToNumberBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("string->number", 1, 2, args); err != nil {
+		return nil, err
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "string->number")
+	}
+	radix, err := radixArgument("string->number", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if n, ok := sexpr.ParseNumber(s, radix); ok {
+		return n, nil
+	}
+	return false, nil
+}
+
+// predicateBuiltin makes type predicate which accepts any object.
+func predicateBuiltin(name string, predicate func(sexpr.Expr) bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArity(name, 1, args); err != nil {
+			return nil, err
+		}
+		return predicate(args[0]), nil
+	}
+}
+
+// numberPredicateBuiltin makes predicate which accepts only numbers.
+func numberPredicateBuiltin(name string, predicate func(sexpr.Expr) bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkNumberArgs(name, 1, args); err != nil {
+			return nil, err
+		}
+		return predicate(args[0]), nil
+	}
+}
+
+// integerPredicateBuiltin makes predicate on integers which checks remainder
+// of division by two.
+func integerPredicateBuiltin(name string, predicate func(remainder sexpr.Expr) bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArity(name, 1, args); err != nil {
+			return nil, err
+		}
+		_, remainder, err := integerDivision(name, args[0], 2, false)
+		if err != nil {
+			return nil, err
+		}
+		return predicate(remainder), nil
+	}
+}
@@ -0,0 +1,222 @@
+package scheme
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestNumbers(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: `(* 4611686018427387904 2)`, want: `9223372036854775808`},
+		{in: `(+ 9223372036854775807 1)`, want: `9223372036854775808`},
+		{in: `(- -9223372036854775808 1)`, want: `-9223372036854775809`},
+		{in: `(- 9223372036854775808 1)`, want: `9223372036854775807`},
+		{in: `(* 99999999999999999999 99999999999999999999)`, want: `9999999999999999999800000000000000000001`},
+		{in: `(/ 1 3)`, want: `1/3`},
+		{in: `(/ 6 -4)`, want: `-3/2`},
+		{in: `(/ 1 2.0)`, want: `0.5`},
+		{in: `(/ 1.0 0)`, want: `+inf.0`},
+		{in: `(+ 1/3 2/3)`, want: `1`},
+		{in: `(+ 1/2 0.5)`, want: `1.0`},
+		{in: `(* 1.5 2)`, want: `3.0`},
+		{in: `(< 1/3 0.34)`, want: `#t`},
+		{in: `(> 9223372036854775808 9223372036854775807)`, want: `#t`},
+		{in: `(= 1 1.0)`, want: `#t`},
+		{in: `(= 1/2 0.5)`, want: `#t`},
+		{in: `(= +nan.0 +nan.0)`, want: `#f`},
+
+		{in: `(quotient 17 5)`, want: `3`},
+		{in: `(quotient -17 5)`, want: `-3`},
+		{in: `(remainder -17 5)`, want: `-2`},
+		{in: `(modulo -17 5)`, want: `3`},
+		{in: `(modulo 17 -5)`, want: `-3`},
+		{in: `(floor-quotient -17 5)`, want: `-4`},
+		{in: `(modulo 17.0 -5)`, want: `-3.0`},
+		{in: `(quotient 100000000000000000000 3)`, want: `33333333333333333333`},
+		{in: `(remainder 100000000000000000000 3)`, want: `1`},
+		{in: `(gcd 32 -36)`, want: `4`},
+		{in: `(gcd)`, want: `0`},
+		{in: `(lcm 32 -36)`, want: `288`},
+		{in: `(numerator 6/4)`, want: `3`},
+		{in: `(denominator 6/4)`, want: `2`},
+		{in: `(denominator 0.5)`, want: `2.0`},
+
+		{in: `(floor -4.3)`, want: `-5.0`},
+		{in: `(ceiling -4.3)`, want: `-4.0`},
+		{in: `(truncate -4.3)`, want: `-4.0`},
+		{in: `(round -4.5)`, want: `-4.0`},
+		{in: `(round 7/2)`, want: `4`},
+		{in: `(round 5/2)`, want: `2`},
+		{in: `(round -7/3)`, want: `-2`},
+		{in: `(floor -7/2)`, want: `-4`},
+		{in: `(ceiling -7/2)`, want: `-3`},
+		{in: `(truncate -7/2)`, want: `-3`},
+		{in: `(floor 7)`, want: `7`},
+
+		{in: `(exact->inexact 1/4)`, want: `0.25`},
+		{in: `(inexact 12345678901234567890)`, want: `1.2345678901234567e19`},
+		{in: `(inexact->exact 0.5)`, want: `1/2`},
+		{in: `(exact 3.0)`, want: `3`},
+
+		{in: `(sqrt 16)`, want: `4`},
+		{in: `(sqrt 1/4)`, want: `1/2`},
+		{in: `(sqrt 2)`, want: `1.4142135623730951`},
+		{in: `(sqrt 16.0)`, want: `4.0`},
+		{in: `(square 1/3)`, want: `1/9`},
+		{in: `(expt 2 100)`, want: `1267650600228229401496703205376`},
+		{in: `(expt 2/3 2)`, want: `4/9`},
+		{in: `(expt 2 -2)`, want: `1/4`},
+		{in: `(expt 0 0)`, want: `1`},
+		{in: `(expt -1 1000000000001)`, want: `-1`},
+		{in: `(expt 4 0.5)`, want: `2.0`},
+		{in: `(expt 2.0 3)`, want: `8.0`},
+		{in: `(exp 0)`, want: `1.0`},
+		{in: `(log 8 2)`, want: `3.0`},
+		{in: `(atan 1 1)`, want: `0.7853981633974483`},
+
+		{in: `(number->string 255 16)`, want: `"ff"`},
+		{in: `(number->string 1/3 2)`, want: `"1/11"`},
+		{in: `(number->string 1.5)`, want: `"1.5"`},
+		{in: `(string->number "1e2")`, want: `100.0`},
+		{in: `(string->number "ff" 16)`, want: `255`},
+		{in: `(string->number "#xff")`, want: `255`},
+		{in: `(string->number "abc")`, want: `#f`},
+
+		{in: `(number? 1/2)`, want: `#t`},
+		{in: `(number? 'a)`, want: `#f`},
+		{in: `(integer? 3.0)`, want: `#t`},
+		{in: `(integer? 3.5)`, want: `#f`},
+		{in: `(integer? 1/2)`, want: `#f`},
+		{in: `(rational? 0.5)`, want: `#t`},
+		{in: `(rational? +inf.0)`, want: `#f`},
+		{in: `(exact? 1/2)`, want: `#t`},
+		{in: `(exact? 0.5)`, want: `#f`},
+		{in: `(inexact? 0.5)`, want: `#t`},
+		{in: `(exact-integer? 100000000000000000000)`, want: `#t`},
+		{in: `(exact-integer? 1.0)`, want: `#f`},
+		{in: `(nan? +nan.0)`, want: `#t`},
+		{in: `(zero? 0.0)`, want: `#t`},
+		{in: `(zero? +nan.0)`, want: `#f`},
+		{in: `(positive? 1/2)`, want: `#t`},
+		{in: `(negative? -0.5)`, want: `#t`},
+		{in: `(odd? 7)`, want: `#t`},
+		{in: `(even? 100000000000000000000)`, want: `#t`},
+		{in: `(even? 4.0)`, want: `#t`},
+		{in: `(eqv? 100000000000000000000 100000000000000000000)`, want: `#t`},
+		{in: `(eqv? 2 2.0)`, want: `#f`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, sexpr.Print(mustEval(t, tt.in)))
+		})
+	}
+}
+
+func TestVariadicArithmetic(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: `(+)`, want: `0`},
+		{in: `(+ 5)`, want: `5`},
+		{in: `(+ 1 2 3 4)`, want: `10`},
+		{in: `(*)`, want: `1`},
+		{in: `(* 2 3 4)`, want: `24`},
+		{in: `(- 5)`, want: `-5`},
+		{in: `(- 1/2)`, want: `-1/2`},
+		{in: `(- 10 1 2 3)`, want: `4`},
+		{in: `(/ 2)`, want: `1/2`},
+		{in: `(/ 0.5)`, want: `2.0`},
+		{in: `(/ 60 2 3 5)`, want: `2`},
+		{in: `(< 1 2 3)`, want: `#t`},
+		{in: `(< 1 3 2)`, want: `#f`},
+		{in: `(< 1)`, want: `#t`},
+		{in: `(> 3 2 1)`, want: `#t`},
+		{in: `(> 3 3 1)`, want: `#f`},
+		{in: `(<= 1 1 2)`, want: `#t`},
+		{in: `(<= 1 2 1)`, want: `#f`},
+		{in: `(>= 2 2 1)`, want: `#t`},
+		{in: `(>= 1 2)`, want: `#f`},
+		{in: `(= 2 2 2.0)`, want: `#t`},
+		{in: `(= 2 2 3)`, want: `#f`},
+		{in: `(= 'a 'a 'a)`, want: `#t`},
+		{in: `(abs -7)`, want: `7`},
+		{in: `(abs -1/2)`, want: `1/2`},
+		{in: `(abs -9223372036854775808)`, want: `9223372036854775808`},
+		{in: `(abs -0.5)`, want: `0.5`},
+		{in: `(min 3 1 2)`, want: `1`},
+		{in: `(max 3 1 2)`, want: `3`},
+		{in: `(max 1 2.0)`, want: `2.0`},
+		{in: `(min 1 2.0)`, want: `1.0`},
+		{in: `(max 1/2 1/3)`, want: `1/2`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, sexpr.Print(mustEval(t, tt.in)))
+		})
+	}
+
+	t.Run("arity errors", func(t *testing.T) {
+		for _, in := range []string{`(-)`, `(/)`, `(<)`, `(>=)`, `(abs)`, `(abs 1 2)`, `(min)`, `(max)`} {
+			assert.Equal(t, KindArity, evalError(t, in).Kind, in)
+		}
+		assert.Equal(
+			t,
+			"Wrong number of arguments passed to -, expected at least 1, got: 0",
+			evalError(t, `(-)`).Error(),
+		)
+	})
+
+	t.Run("type errors", func(t *testing.T) {
+		err := evalError(t, `(+ 1 2 'three)`)
+		assert.Equal(t, KindWrongType, err.Kind)
+		assert.Equal(t, []sexpr.Expr{sexpr.Symbol("three")}, err.Irritants)
+		assert.Contains(t, err.Message, "third argument")
+
+		assert.Equal(t, KindWrongType, evalError(t, `(< 1 'a 0)`).Kind)
+		assert.Equal(t, KindWrongType, evalError(t, `(max 1 "2")`).Kind)
+	})
+}
+
+func TestNumberErrors(t *testing.T) {
+	cases := []struct {
+		in   string
+		kind ErrorKind
+	}{
+		{in: `(/ 1 0)`, kind: KindDivideByZero},
+		{in: `(quotient 1 0)`, kind: KindDivideByZero},
+		{in: `(expt 0 -1)`, kind: KindDivideByZero},
+		{in: `(expt 2 1000000000000)`, kind: KindBadRange},
+		{in: `(expt 1/3 -100000000000000000000)`, kind: KindBadRange},
+		{in: `(quotient 1.5 1)`, kind: KindWrongType},
+		{in: `(+ 1 "2")`, kind: KindWrongType},
+		{in: `(sqrt -4)`, kind: KindBadRange},
+		{in: `(exact +inf.0)`, kind: KindBadRange},
+		{in: `(number->string 10 7)`, kind: KindBadRange},
+		{in: `(number->string 1.5 2)`, kind: KindBadRange},
+		{in: `(exact? 'a)`, kind: KindWrongType},
+		{in: `(odd? 1.5)`, kind: KindWrongType},
+		{in: `(gcd 1.5)`, kind: KindWrongType},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.kind, evalError(t, tt.in).Kind)
+		})
+	}
+}
+
+func TestNumberTypes(t *testing.T) {
+	assert.Equal(t, big.NewRat(1, 3), mustEval(t, `(/ 1 3)`))
+	assert.Equal(t, 0.5, mustEval(t, `(/ 1 2.0)`))
+	assert.Equal(t, 3, mustEval(t, `(* 3/2 2)`))
+}
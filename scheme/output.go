@@ -0,0 +1,106 @@
+package scheme
+
+import (
+	"io"
+	"os"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// OutputPort is a port which writes to Writer.
+type OutputPort struct {
+	Writer io.Writer
+}
+
+func (*OutputPort) String() string {
+	return "#[output-port]"
+}
+
+func addOutputBuiltins(env *Environment) {
+	AddFuncToEnv(env, "current-output-port", func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArity("current-output-port", 0, args); err != nil {
+			return nil, err
+		}
+		return &OutputPort{Writer: currentOutput(env)}, nil
+	})
+	AddFuncToEnv(env, "current-error-port", func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArity("current-error-port", 0, args); err != nil {
+			return nil, err
+		}
+		return &OutputPort{Writer: currentError(env)}, nil
+	})
+	AddFuncToEnv(env, "display", writeBuiltin("display", func(e sexpr.Expr) (string, bool) {
+		return sexpr.Display(e), true
+	}))
+	AddFuncToEnv(env, "write", writeBuiltin("write", func(e sexpr.Expr) (string, bool) {
+		return sexpr.Print(e), true
+	}))
+	AddFuncToEnv(env, "write-string", writeBuiltin("write-string", func(e sexpr.Expr) (string, bool) {
+		s, ok := e.(string)
+		return s, ok
+	}))
+	AddFuncToEnv(env, "write-char", writeBuiltin("write-char", func(e sexpr.Expr) (string, bool) {
+		c, ok := e.(sexpr.Char)
+		return string(c), ok
+	}))
+	AddFuncToEnv(env, "newline", func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArityRange("newline", 0, 1, args); err != nil {
+			return nil, err
+		}
+		writer, err := outputArgument(args, 0, "newline", env)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(writer, "\n")
+		return nil, err
+	})
+}
+
+// currentOutput returns writer of current output port.
+func currentOutput(env *Environment) io.Writer {
+	if env.interpreter == nil {
+		return os.Stdout
+	}
+	return env.interpreter.stdout
+}
+
+// currentError returns writer of current error port.
+func currentError(env *Environment) io.Writer {
+	if env.interpreter == nil {
+		return os.Stderr
+	}
+	return env.interpreter.stderr
+}
+
+// outputArgument returns writer of optional port argument with index
+// position or of current output port when it is omitted.
+func outputArgument(args []sexpr.Expr, position int, name string, env *Environment) (io.Writer, error) {
+	if len(args) <= position {
+		return currentOutput(env), nil
+	}
+	port, ok := args[position].(*OutputPort)
+	if !ok {
+		return nil, errWrongType(args[position], position, name)
+	}
+	return port.Writer, nil
+}
+
+// writeBuiltin returns procedure (name obj [port]) which writes obj printed by
+// print, print reports false when obj has incorrect type.
+func writeBuiltin(name string, print func(sexpr.Expr) (string, bool)) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkArityRange(name, 1, 2, args); err != nil {
+			return nil, err
+		}
+		text, ok := print(args[0])
+		if !ok {
+			return nil, errWrongType(args[0], 0, name)
+		}
+		writer, err := outputArgument(args, 1, name, env)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(writer, text)
+		return nil, err
+	}
+}
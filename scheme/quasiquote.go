@@ -0,0 +1,77 @@
+package scheme
+
+import (
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// quotation returns x of (keyword x) form.
+func quotation(expr sexpr.Expr, keyword sexpr.Symbol, env *Environment) (sexpr.Expr, bool) {
+	pair, ok := expr.(*sexpr.Pair)
+	if !ok || syntaxKeyword(pair.Car, env) != keyword {
+		return nil, false
+	}
+	argument, ok := pair.Cdr.(*sexpr.Pair)
+	if !ok || argument.Cdr != sexpr.Nil {
+		return nil, false
+	}
+	return argument.Car, true
+}
+
+// evalQuasiquote instantiates template of (quasiquote template) and passes it
+// to then. Unquoted expressions are evaluated only at depth 1, nested
+// quasiquote increases depth and unquote decreases it. Vector templates are
+// instantiated as lists of their elements.
+func evalQuasiquote(template sexpr.Expr, depth int, env *Environment, then func(sexpr.Expr) (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	if vector, ok := template.(*sexpr.Vector); ok {
+		return evalQuasiquote(sexpr.List(vector.Elements...), depth, env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+			elements, _ := sexpr.ToSlice(value)
+			return then(&sexpr.Vector{Elements: elements})
+		})
+	}
+	pair, ok := template.(*sexpr.Pair)
+	if !ok {
+		return then(stripSyntax(template))
+	}
+
+	if expr, ok := quotation(pair, "unquote", env); ok {
+		if depth == 1 {
+			return evalThen(expr, env, then)
+		}
+		return nestedQuotation("unquote", expr, depth-1, env, then)
+	}
+	if expr, ok := quotation(pair, "quasiquote", env); ok {
+		return nestedQuotation("quasiquote", expr, depth+1, env, then)
+	}
+	if expr, ok := quotation(pair, "unquote-splicing", env); ok {
+		if depth == 1 {
+			err := newError(KindSyntax, "unquote-splicing outside of list:", pair)
+			err.Expr = pair
+			return nil, nil, err
+		}
+		return nestedQuotation("unquote-splicing", expr, depth-1, env, then)
+	}
+
+	// elements are instantiated from left to right like operands
+	if expr, ok := quotation(pair.Car, "unquote-splicing", env); ok && depth == 1 {
+		return evalThen(expr, env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+			elements, ok := sexpr.ToSlice(value)
+			if !ok {
+				return nil, nil, withExpr(errWrongType(value, 0, "unquote-splicing"), pair.Car)
+			}
+			return evalQuasiquote(pair.Cdr, depth, env, func(rest sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+				return then(sexpr.ListWithTail(elements, rest))
+			})
+		})
+	}
+	return evalQuasiquote(pair.Car, depth, env, func(first sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return evalQuasiquote(pair.Cdr, depth, env, func(rest sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+			return then(sexpr.Cons(first, rest))
+		})
+	})
+}
+
+func nestedQuotation(keyword sexpr.Symbol, template sexpr.Expr, depth int, env *Environment, then func(sexpr.Expr) (sexpr.Expr, *tailCall, error)) (sexpr.Expr, *tailCall, error) {
+	return evalQuasiquote(template, depth, env, func(value sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return then(sexpr.List(keyword, value))
+	})
+}
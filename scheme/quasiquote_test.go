@@ -0,0 +1,79 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestQuasiquote(t *testing.T) {
+	t.Run("without unquote it is quote", func(t *testing.T) {
+		assert.Equal(t, sexpr.List(sexpr.Symbol("a"), 1), mustEval(t, "`(a 1)"))
+		assert.Equal(t, sexpr.Symbol("a"), mustEval(t, "`a"))
+	})
+
+	t.Run("unquote", func(t *testing.T) {
+		assert.Equal(t, "(list 3 4)", sexpr.Print(mustEval(t, "`(list ,(+ 1 2) 4)")))
+		assert.Equal(t, "(1 . 2)", sexpr.Print(mustEval(t, "(let ((b 2)) `(1 . ,b))")))
+	})
+
+	t.Run("unquote-splicing", func(t *testing.T) {
+		assert.Equal(t,
+			"(a 1 2 3 b)",
+			sexpr.Print(mustEval(t, "(let ((l '(1 2 3))) `(a ,@l b))")),
+		)
+		assert.Equal(t, "(1 . 2)", sexpr.Print(mustEval(t, "`(,@'(1) . 2)")))
+		assert.Equal(t, "()", sexpr.Print(mustEval(t, "`(,@'())")))
+		assert.Equal(t, KindWrongType, evalError(t, "`(,@1)").Kind)
+	})
+
+	t.Run("vector", func(t *testing.T) {
+		assert.Equal(t,
+			"#(10 5 2 4 3 8)",
+			sexpr.Print(mustEval(t, "`#(10 5 ,(sqrt 4) ,@(list 4 3) 8)")),
+		)
+	})
+
+	t.Run("unquotes are evaluated from left to right", func(t *testing.T) {
+		assert.Equal(t, "((1 2 3 . 4) (4 3 2 1))", sexpr.Print(mustEval(t, `
+			(let* ((order '())
+			       (visit (lambda (n) (set! order (cons n order)) n))
+			       (result `+"`"+`(,(visit 1) ,@(list (visit 2)) ,(visit 3) . ,(visit 4))))
+				(list result order))`)))
+	})
+
+	t.Run("continuation of unquote is reentered", func(t *testing.T) {
+		assert.Equal(t, "((a 3 b) 3)", sexpr.Print(mustEval(t, `
+			(let ((k #f) (n 0))
+				(let ((result `+"`"+`(a ,(call/cc (lambda (c) (set! k c) 1)) b)))
+					(set! n (+ n 1))
+					(if (< n 3) (k (+ n 1)) (list result n))))`)))
+	})
+
+	t.Run("nested quasiquote", func(t *testing.T) {
+		assert.Equal(t,
+			"(a `(b ,(c 3)))",
+			sexpr.Print(mustEval(t, "`(a `(b ,(c ,(+ 1 2))))")),
+		)
+		assert.Equal(t,
+			"`(1 ,@(2 3))",
+			sexpr.Print(mustEval(t, "(let ((x '(2 3))) ``(1 ,@,x))")),
+		)
+	})
+
+	t.Run("unquote outside of quasiquote", func(t *testing.T) {
+		assert.Equal(t, KindSyntax, evalError(t, ",a").Kind)
+		assert.Equal(t, KindSyntax, evalError(t, "`,@a").Kind)
+	})
+
+	t.Run("in macros", func(t *testing.T) {
+		// arrange
+		mustEval(t, "(defmacro my-assert (expr) `(if ,expr 'ok (list 'failed ',expr)))")
+
+		// assert
+		assert.Equal(t, sexpr.Symbol("ok"), mustEval(t, "(my-assert (= 1 1))"))
+		assert.Equal(t, "(failed (= 1 2))", sexpr.Print(mustEval(t, "(my-assert (= 1 2))")))
+	})
+}
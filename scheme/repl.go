@@ -1,38 +1,37 @@
 package scheme
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 
 	"github.com/adzeitor/goscheme/sexpr"
 )
 
-func scan(scanner *bufio.Scanner) bool {
-	return scanner.Scan()
-}
-
 func RunRepl(
-	env Environment,
+	env *Environment,
 	input io.Reader,
 	output io.Writer,
 ) {
-	// FIXME: it is not fully suitable here because newline may break sexpr which
-	// results in parser error.
-	buf := bufio.NewScanner(input)
-
-	var result sexpr.Expr
-	line := ""
-	fmt.Fprint(output, "> ")
-	for scan(buf) {
-		line += buf.Text()
-		if !sexpr.IsComplete(line) {
-			continue
+	reader := sexpr.NewReader(input)
+	for {
+		fmt.Fprint(output, "> ")
+		expr, err := reader.Read()
+		if err == io.EOF {
+			return
+		}
+		if _, ok := err.(*sexpr.ParseError); err != nil && !ok {
+			fmt.Fprintln(output, "error:", err)
+			return
+		}
+		var result sexpr.Expr
+		if err == nil {
+			result, err = evalToplevel(expr, env)
+		}
+		if err != nil {
+			fmt.Fprintln(output, "exception:", err)
+		} else {
+			fmt.Fprintln(output, sexpr.Print(result))
 		}
-		result, env = EvalInEnvironment(line, env)
-		fmt.Fprintln(output, sexpr.Print(result))
 		fmt.Fprintln(output)
-		line = ""
-		fmt.Fprint(output, "> ")
 	}
 }
@@ -68,4 +68,44 @@ func TestRunRepl(t *testing.T) {
 		// assert
 		assert.Contains(t, output.String(), "42")
 	})
+
+	t.Run("comments", func(t *testing.T) {
+		// arrange
+		input := bytes.NewBufferString("; first line comment\n(+ 40 ; inline comment\n 2)\n")
+		output := bytes.NewBufferString("")
+
+		// act
+		RunRepl(DefaultEnvironment(), input, output)
+
+		// assert
+		assert.Contains(t, output.String(), "42")
+		assert.NotContains(t, output.String(), "exception")
+	})
+
+	t.Run("prints errors", func(t *testing.T) {
+		// arrange
+		input := bytes.NewBufferString("(car 1)\n(+ 1 2)\n")
+		output := bytes.NewBufferString("")
+
+		// act
+		RunRepl(DefaultEnvironment(), input, output)
+
+		// assert
+		assert.Contains(t, output.String(), "exception: The object, passed as the first argument to car")
+		assert.Contains(t, output.String(), "3")
+	})
+
+	t.Run("continues after syntax error", func(t *testing.T) {
+		// arrange
+		input := bytes.NewBufferString("(+ 1 ]\n(+ 1\n 2) 4\n")
+		output := bytes.NewBufferString("")
+
+		// act
+		RunRepl(DefaultEnvironment(), input, output)
+
+		// assert
+		assert.Contains(t, output.String(), `exception: 1:6: unexpected "]"`)
+		assert.Contains(t, output.String(), "3")
+		assert.Contains(t, output.String(), "4")
+	})
 }
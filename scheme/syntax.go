@@ -0,0 +1,258 @@
+package scheme
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// Macro is a syntactic keyword. Its transformer rewrites the whole form into
+// an expansion, which is evaluated in place of the form.
+type Macro struct {
+	Name        sexpr.Symbol
+	Transformer func(form sexpr.Expr, env *Environment) (sexpr.Expr, error)
+}
+
+func (macro *Macro) String() string {
+	return "#[syntax " + string(macro.Name) + "]"
+}
+
+// Alias is an identifier inserted into expansion by a macro template. When
+// the expansion binds it, the binding is visible only to the same alias, so
+// it cannot capture variables of the user. Otherwise it refers to binding of
+// Name in the environment where the macro was defined, so local bindings at
+// the place of use do not change meaning of the template.
+type Alias struct {
+	// Name is sexpr.Symbol or *Alias when a macro is defined by a macro.
+	Name sexpr.Expr
+	Env  *Environment
+	// key is a unique symbol under which the alias is bound.
+	key sexpr.Symbol
+}
+
+var aliasCount uint64
+
+func newAlias(name sexpr.Expr, env *Environment) *Alias {
+	n := atomic.AddUint64(&aliasCount, 1)
+	return &Alias{
+		Name: name,
+		Env:  env,
+		key:  sexpr.Symbol(string(baseName(name)) + "\x00" + strconv.FormatUint(n, 10)),
+	}
+}
+
+func (alias *Alias) String() string {
+	return string(baseName(alias))
+}
+
+func isIdentifier(expr sexpr.Expr) bool {
+	switch expr.(type) {
+	case sexpr.Symbol, *Alias:
+		return true
+	}
+	return false
+}
+
+// baseName returns symbol the identifier was written as.
+func baseName(id sexpr.Expr) sexpr.Symbol {
+	for {
+		alias, ok := id.(*Alias)
+		if !ok {
+			symbol, _ := id.(sexpr.Symbol)
+			return symbol
+		}
+		id = alias.Name
+	}
+}
+
+// bindingName returns symbol under which binding forms bind the identifier.
+func bindingName(id sexpr.Expr) (sexpr.Symbol, bool) {
+	switch id := id.(type) {
+	case sexpr.Symbol:
+		return id, true
+	case *Alias:
+		return id.key, true
+	}
+	return "", false
+}
+
+// coreKeywords are names of special forms and of auxiliary syntax used in
+// them, like else of cond.
+var coreKeywords = map[sexpr.Symbol]bool{
+	"quote": true, "quasiquote": true, "unquote": true, "unquote-splicing": true,
+	"if": true, "define": true, "set!": true, "lambda": true, "begin": true,
+	"define-syntax": true, "let-syntax": true, "letrec-syntax": true, "syntax-rules": true,
+	"define-macro": true, "defmacro": true,
+	"cond": true, "case": true, "else": true, "=>": true,
+	"and": true, "or": true, "when": true, "unless": true, "do": true, "guard": true,
+	"let": true, "let*": true, "letrec": true, "letrec*": true, "let-values": true,
+}
+
+// syntaxKeyword returns name of special form the identifier refers to, or
+// empty symbol when it is not a core keyword or it is bound as variable.
+func syntaxKeyword(id sexpr.Expr, env *Environment) sexpr.Symbol {
+	for {
+		alias, ok := id.(*Alias)
+		if !ok {
+			symbol, _ := id.(sexpr.Symbol)
+			if !coreKeywords[symbol] {
+				return ""
+			}
+			if _, bound := env.Lookup(symbol); bound {
+				return ""
+			}
+			return symbol
+		}
+		if _, bound := env.Lookup(alias.key); bound {
+			return ""
+		}
+		id, env = alias.Name, alias.Env
+	}
+}
+
+// lookupIdentifier finds value of symbol or alias.
+func lookupIdentifier(id sexpr.Expr, env *Environment) (sexpr.Expr, bool) {
+	for {
+		alias, ok := id.(*Alias)
+		if !ok {
+			return env.Lookup(id.(sexpr.Symbol))
+		}
+		if value, ok := env.Lookup(alias.key); ok {
+			return value, true
+		}
+		id, env = alias.Name, alias.Env
+	}
+}
+
+// assignIdentifier assigns value to existing binding of symbol or alias.
+func assignIdentifier(id sexpr.Expr, value sexpr.Expr, env *Environment) bool {
+	for {
+		alias, ok := id.(*Alias)
+		if !ok {
+			return env.Set(id.(sexpr.Symbol), value)
+		}
+		if env.Set(alias.key, value) {
+			return true
+		}
+		id, env = alias.Name, alias.Env
+	}
+}
+
+// resolveIdentifier returns the frame which binds symbol or alias and the
+// name bound there, frame is nil for unbound identifier.
+func resolveIdentifier(id sexpr.Expr, env *Environment) (*Environment, sexpr.Symbol) {
+	for {
+		alias, ok := id.(*Alias)
+		if !ok {
+			symbol := id.(sexpr.Symbol)
+			return env.frameOf(symbol), symbol
+		}
+		if frame := env.frameOf(alias.key); frame != nil {
+			return frame, alias.key
+		}
+		id, env = alias.Name, alias.Env
+	}
+}
+
+// sameBinding reports whether identifier a used in aEnv and b used in bEnv
+// refer to the same binding, unbound identifiers are the same when they have
+// the same name.
+func sameBinding(a sexpr.Expr, aEnv *Environment, b sexpr.Expr, bEnv *Environment) bool {
+	aFrame, aName := resolveIdentifier(a, aEnv)
+	bFrame, bName := resolveIdentifier(b, bEnv)
+	return aFrame == bFrame && aName == bName
+}
+
+// stripSyntax replaces aliases by the symbols they were written as, it is
+// used for quoted data. Expression is returned as is when it has no aliases.
+func stripSyntax(expr sexpr.Expr) sexpr.Expr {
+	switch value := expr.(type) {
+	case *Alias:
+		return baseName(value)
+	case *sexpr.Pair:
+		car := stripSyntax(value.Car)
+		cdr := stripSyntax(value.Cdr)
+		if car == value.Car && cdr == value.Cdr {
+			return value
+		}
+		return sexpr.Cons(car, cdr)
+	case *sexpr.Vector:
+		elements := make([]sexpr.Expr, len(value.Elements))
+		for i, element := range value.Elements {
+			elements[i] = stripSyntax(element)
+		}
+		return &sexpr.Vector{Elements: elements}
+	}
+	return expr
+}
+
+// makeTransformer evaluates transformer specification of define-syntax,
+// let-syntax and letrec-syntax.
+func makeTransformer(name sexpr.Expr, spec sexpr.Expr, env *Environment) (*Macro, error) {
+	list, ok := sexpr.ToSlice(spec)
+	if !ok || len(list) == 0 || syntaxKeyword(list[0], env) != "syntax-rules" {
+		return nil, newError(KindSyntax, "Syntactic binding value must be a keyword:", spec)
+	}
+	rules, err := makeSyntaxRules(spec, list, env)
+	if err != nil {
+		return nil, err
+	}
+	return &Macro{Name: baseName(name), Transformer: rules.transform}, nil
+}
+
+// evalDefineSyntax evaluates (define-syntax name transformer).
+func evalDefineSyntax(form sexpr.Expr, list []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if len(list) != 3 {
+		return nil, errIllFormed(form)
+	}
+	name, ok := bindingName(list[1])
+	if !ok {
+		return nil, errIllFormed(form)
+	}
+	macro, err := makeTransformer(list[1], list[2], env)
+	if err != nil {
+		return nil, withExpr(err, form)
+	}
+	env.Define(name, macro)
+	return baseName(list[1]), nil
+}
+
+// evalLetSyntax evaluates (let-syntax ((name transformer) ...) body...), for
+// letrec-syntax transformers are defined in the environment of the body, so
+// they can refer to each other.
+func evalLetSyntax(form sexpr.Expr, list []sexpr.Expr, env *Environment, recursive bool) (sexpr.Expr, *tailCall, error) {
+	if len(list) < 3 {
+		return nil, nil, errIllFormed(form)
+	}
+	bindings, err := parseBindings(form, list[1], true)
+	if err != nil {
+		return nil, nil, err
+	}
+	bodyEnv := env.Extend()
+	definitionEnv := env
+	if recursive {
+		definitionEnv = bodyEnv
+	}
+	for _, b := range bindings {
+		macro, err := makeTransformer(b.ID, b.Init, definitionEnv)
+		if err != nil {
+			return nil, nil, withExpr(err, form)
+		}
+		bodyEnv.Define(b.Name, macro)
+	}
+	return evalSequence(list[2:], bodyEnv)
+}
+
+// lookupVariable finds value of identifier which is used as an expression.
+// Unbound keywords evaluate to themselves.
+func lookupVariable(id sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	value, ok := lookupIdentifier(id, env)
+	if !ok {
+		if symbol, isSymbol := id.(sexpr.Symbol); isSymbol && isKeyword(symbol) {
+			return symbol, nil
+		}
+		return nil, errUnboundVariable(baseName(id))
+	}
+	return value, nil
+}
@@ -0,0 +1,306 @@
+package scheme
+
+import (
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+const ellipsis = sexpr.Symbol("...")
+
+// syntaxRules is a transformer defined by
+// (syntax-rules [ellipsis] (literal ...) (pattern template) ...).
+type syntaxRules struct {
+	// ellipsis is a custom ellipsis identifier or nil for `...`.
+	ellipsis sexpr.Expr
+	literals []sexpr.Expr
+	rules    [][2]sexpr.Expr
+	// env is the environment of macro definition, free identifiers of
+	// templates refer to it.
+	env *Environment
+}
+
+// patternMatch is the part of form matched by pattern variable. Variables
+// followed by ellipsis are bound to the sequence of matches.
+type patternMatch struct {
+	Expr     sexpr.Expr
+	Sequence []*patternMatch
+	Repeated bool
+}
+
+type patternBindings map[sexpr.Expr]*patternMatch
+
+func makeSyntaxRules(spec sexpr.Expr, list []sexpr.Expr, env *Environment) (*syntaxRules, error) {
+	rules := &syntaxRules{env: env}
+	list = list[1:]
+	if len(list) > 0 && isIdentifier(list[0]) {
+		rules.ellipsis = list[0]
+		list = list[1:]
+	}
+	if len(list) == 0 {
+		return nil, errIllFormed(spec)
+	}
+	literals, ok := sexpr.ToSlice(list[0])
+	if !ok {
+		return nil, errIllFormed(spec)
+	}
+	for _, literal := range literals {
+		if !isIdentifier(literal) {
+			return nil, errIllFormed(spec)
+		}
+	}
+	rules.literals = literals
+
+	for _, rule := range list[1:] {
+		rule, ok := sexpr.ToSlice(rule)
+		if !ok || len(rule) != 2 {
+			return nil, errIllFormed(spec)
+		}
+		if _, ok := rule[0].(*sexpr.Pair); !ok {
+			return nil, errIllFormed(spec)
+		}
+		rules.rules = append(rules.rules, [2]sexpr.Expr{rule[0], rule[1]})
+	}
+	return rules, nil
+}
+
+// transform expands form by the first rule which pattern matches it. The
+// keyword position of patterns is ignored.
+func (r *syntaxRules) transform(form sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	arguments := form.(*sexpr.Pair).Cdr
+	for _, rule := range r.rules {
+		bindings := patternBindings{}
+		if !r.match(rule[0].(*sexpr.Pair).Cdr, arguments, env, bindings) {
+			continue
+		}
+		return r.expand(rule[1], bindings, make(map[sexpr.Expr]*Alias), false)
+	}
+	return nil, errIllFormed(form)
+}
+
+func (r *syntaxRules) isEllipsis(expr sexpr.Expr) bool {
+	if r.ellipsis != nil {
+		return expr == r.ellipsis
+	}
+	return isIdentifier(expr) && baseName(expr) == ellipsis
+}
+
+func (r *syntaxRules) isLiteral(id sexpr.Expr) bool {
+	for _, literal := range r.literals {
+		if literal == id {
+			return true
+		}
+	}
+	return false
+}
+
+// match matches form used in env against pattern. Literals match identifiers
+// which refer to the same binding as the literal in the environment of macro
+// definition.
+func (r *syntaxRules) match(pattern sexpr.Expr, form sexpr.Expr, env *Environment, bindings patternBindings) bool {
+	switch p := pattern.(type) {
+	case sexpr.Symbol, *Alias:
+		switch {
+		case r.isLiteral(p):
+			return isIdentifier(form) && sameBinding(form, env, p, r.env)
+		case baseName(p) == "_":
+			return true
+		}
+		bindings[p] = &patternMatch{Expr: form}
+		return true
+	case *sexpr.Pair:
+		if next, ok := p.Cdr.(*sexpr.Pair); ok && r.isEllipsis(next.Car) {
+			return r.matchEllipsis(p.Car, next.Cdr, form, env, bindings)
+		}
+		f, ok := form.(*sexpr.Pair)
+		return ok && r.match(p.Car, f.Car, env, bindings) && r.match(p.Cdr, f.Cdr, env, bindings)
+	case *sexpr.Vector:
+		f, ok := form.(*sexpr.Vector)
+		return ok && r.match(sexpr.List(p.Elements...), sexpr.List(f.Elements...), env, bindings)
+	case sexpr.EmptyList:
+		return form == sexpr.Nil
+	}
+	return sexpr.Equal(pattern, form)
+}
+
+// matchEllipsis matches (element ... . tail) pattern, element takes as many
+// items of form as possible leaving enough of them for tail.
+func (r *syntaxRules) matchEllipsis(element sexpr.Expr, tail sexpr.Expr, form sexpr.Expr, env *Environment, bindings patternBindings) bool {
+	tailLength := 0
+	for p, ok := tail.(*sexpr.Pair); ok; p, ok = p.Cdr.(*sexpr.Pair) {
+		tailLength++
+	}
+	var items []sexpr.Expr
+	rest := form
+	for f, ok := rest.(*sexpr.Pair); ok; f, ok = rest.(*sexpr.Pair) {
+		items = append(items, f.Car)
+		rest = f.Cdr
+	}
+	count := len(items) - tailLength
+	if count < 0 {
+		return false
+	}
+
+	matches := make(map[sexpr.Expr]*patternMatch)
+	for _, variable := range r.patternVariables(element, nil) {
+		matches[variable] = &patternMatch{Repeated: true}
+		bindings[variable] = matches[variable]
+	}
+	for _, item := range items[:count] {
+		itemBindings := patternBindings{}
+		if !r.match(element, item, env, itemBindings) {
+			return false
+		}
+		for variable, match := range matches {
+			match.Sequence = append(match.Sequence, itemBindings[variable])
+		}
+	}
+
+	rest = form
+	for i := 0; i < count; i++ {
+		rest = rest.(*sexpr.Pair).Cdr
+	}
+	return r.match(tail, rest, env, bindings)
+}
+
+func (r *syntaxRules) patternVariables(pattern sexpr.Expr, variables []sexpr.Expr) []sexpr.Expr {
+	switch p := pattern.(type) {
+	case sexpr.Symbol, *Alias:
+		if !r.isLiteral(p) && !r.isEllipsis(p) && baseName(p) != "_" {
+			variables = append(variables, p)
+		}
+	case *sexpr.Pair:
+		variables = r.patternVariables(p.Car, variables)
+		variables = r.patternVariables(p.Cdr, variables)
+	case *sexpr.Vector:
+		variables = r.patternVariables(sexpr.List(p.Elements...), variables)
+	}
+	return variables
+}
+
+// expand instantiates template. Identifiers which are not pattern variables
+// are renamed to aliases, the same identifier gets the same alias within one
+// expansion.
+func (r *syntaxRules) expand(template sexpr.Expr, bindings patternBindings, aliases map[sexpr.Expr]*Alias, escaped bool) (sexpr.Expr, error) {
+	switch t := template.(type) {
+	case sexpr.Symbol, *Alias:
+		if match, ok := bindings[t]; ok {
+			if match.Repeated {
+				return nil, newError(KindSyntax, "Pattern variable used without ellipsis:", t)
+			}
+			return match.Expr, nil
+		}
+		if symbol, ok := t.(sexpr.Symbol); ok && len(symbol) > 2 && symbol[:2] == "#!" {
+			return symbol, nil
+		}
+		alias, ok := aliases[t]
+		if !ok {
+			alias = newAlias(t, r.env)
+			aliases[t] = alias
+		}
+		return alias, nil
+	case *sexpr.Pair:
+		// (... template) inserts template with ellipsis as ordinary identifier
+		if !escaped && r.isEllipsis(t.Car) {
+			escapedTemplate, ok := t.Cdr.(*sexpr.Pair)
+			if !ok || escapedTemplate.Cdr != sexpr.Nil {
+				return nil, newError(KindSyntax, "Ill-formed ellipsis escape:", t)
+			}
+			return r.expand(escapedTemplate.Car, bindings, aliases, true)
+		}
+
+		// element followed by one or more ellipses
+		depth := 0
+		rest := t.Cdr
+		for next, ok := rest.(*sexpr.Pair); ok && !escaped && r.isEllipsis(next.Car); next, ok = rest.(*sexpr.Pair) {
+			depth++
+			rest = next.Cdr
+		}
+		if depth > 0 {
+			items, err := r.expandEllipsis(t.Car, depth, bindings, aliases)
+			if err != nil {
+				return nil, err
+			}
+			tail, err := r.expand(rest, bindings, aliases, escaped)
+			if err != nil {
+				return nil, err
+			}
+			return sexpr.ListWithTail(items, tail), nil
+		}
+
+		car, err := r.expand(t.Car, bindings, aliases, escaped)
+		if err != nil {
+			return nil, err
+		}
+		cdr, err := r.expand(t.Cdr, bindings, aliases, escaped)
+		if err != nil {
+			return nil, err
+		}
+		return sexpr.Cons(car, cdr), nil
+	case *sexpr.Vector:
+		expansion, err := r.expand(sexpr.List(t.Elements...), bindings, aliases, escaped)
+		if err != nil {
+			return nil, err
+		}
+		elements, _ := sexpr.ToSlice(expansion)
+		return &sexpr.Vector{Elements: elements}, nil
+	}
+	return template, nil
+}
+
+// expandEllipsis instantiates element once for each match of the repeated
+// pattern variables it contains.
+func (r *syntaxRules) expandEllipsis(element sexpr.Expr, depth int, bindings patternBindings, aliases map[sexpr.Expr]*Alias) ([]sexpr.Expr, error) {
+	var repeated []sexpr.Expr
+	count := -1
+	for _, variable := range r.templateIdentifiers(element, nil) {
+		match, ok := bindings[variable]
+		if !ok || !match.Repeated {
+			continue
+		}
+		if count >= 0 && len(match.Sequence) != count {
+			return nil, newError(KindSyntax, "Pattern variables under ellipsis matched different number of items:", element)
+		}
+		count = len(match.Sequence)
+		repeated = append(repeated, variable)
+	}
+	if len(repeated) == 0 {
+		return nil, newError(KindSyntax, "No pattern variable to repeat in template:", element)
+	}
+
+	var items []sexpr.Expr
+	for i := 0; i < count; i++ {
+		itemBindings := make(patternBindings, len(bindings))
+		for variable, match := range bindings {
+			itemBindings[variable] = match
+		}
+		for _, variable := range repeated {
+			itemBindings[variable] = bindings[variable].Sequence[i]
+		}
+		if depth > 1 {
+			nested, err := r.expandEllipsis(element, depth-1, itemBindings, aliases)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, nested...)
+			continue
+		}
+		item, err := r.expand(element, itemBindings, aliases, false)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *syntaxRules) templateIdentifiers(template sexpr.Expr, identifiers []sexpr.Expr) []sexpr.Expr {
+	switch t := template.(type) {
+	case sexpr.Symbol, *Alias:
+		identifiers = append(identifiers, t)
+	case *sexpr.Pair:
+		identifiers = r.templateIdentifiers(t.Car, identifiers)
+		identifiers = r.templateIdentifiers(t.Cdr, identifiers)
+	case *sexpr.Vector:
+		identifiers = r.templateIdentifiers(sexpr.List(t.Elements...), identifiers)
+	}
+	return identifiers
+}
@@ -0,0 +1,227 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestSyntaxRules(t *testing.T) {
+	t.Run("simple macro", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax my-if
+				(syntax-rules ()
+					((_ c then-branch else-branch) (cond (c then-branch) (else else-branch)))))
+		`)
+
+		// assert
+		assert.Equal(t, 1, mustEval(t, `(my-if #t 1 2)`))
+		assert.Equal(t, 2, mustEval(t, `(my-if #f 1 2)`))
+		assert.Equal(t, KindSyntax, evalError(t, `(my-if #t 1)`).Kind)
+	})
+
+	t.Run("arguments are not evaluated", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax swap!
+				(syntax-rules ()
+					((_ a b) (let ((tmp a)) (set! a b) (set! b tmp)))))
+		`)
+
+		// act
+		result := mustEval(t, `
+			(let ((x 1) (y 2))
+				(swap! x y)
+				(list x y))`)
+
+		// assert
+		assert.Equal(t, sexpr.List(2, 1), result)
+	})
+
+	t.Run("introduced bindings do not capture user variables", func(t *testing.T) {
+		result := mustEval(t, `
+			(let ((tmp 1) (other 2))
+				(swap! tmp other)
+				(list tmp other))`)
+
+		assert.Equal(t, sexpr.List(2, 1), result)
+	})
+
+	t.Run("free identifiers refer to definition environment", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax first-of
+				(syntax-rules ()
+					((_ l) (car l))))
+		`)
+
+		// act
+		result := mustEval(t, `
+			(let ((car (lambda (x) 'shadowed)))
+				(first-of '(1 2)))`)
+
+		// assert
+		assert.Equal(t, 1, result)
+	})
+
+	t.Run("ellipsis", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax my-let
+				(syntax-rules ()
+					((_ ((name value) ...) body1 body2 ...)
+						((lambda (name ...) body1 body2 ...) value ...))))
+		`)
+
+		// assert
+		assert.Equal(t, 3, mustEval(t, `(my-let ((a 1) (b 2)) (+ a b))`))
+		assert.Equal(t, 5, mustEval(t, `(my-let () 5)`))
+	})
+
+	t.Run("nested ellipsis", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax flatten
+				(syntax-rules ()
+					((_ (a ...) ...) '(a ... ...))))
+		`)
+
+		// assert
+		assert.Equal(t, sexpr.List(1, 2, 3, 4), mustEval(t, `(flatten (1 2) () (3 4))`))
+	})
+
+	t.Run("ellipsis followed by tail patterns", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax last-of
+				(syntax-rules ()
+					((_ x ... y) 'y)))
+		`)
+
+		// assert
+		assert.Equal(t, 3, mustEval(t, `(last-of 1 2 3)`))
+		assert.Equal(t, 1, mustEval(t, `(last-of 1)`))
+	})
+
+	t.Run("literals", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax arrow
+				(syntax-rules (=>)
+					((_ a => b) (list a b))
+					((_ a b) 'no-arrow)))
+		`)
+
+		// assert
+		assert.Equal(t, sexpr.List(1, 2), mustEval(t, `(arrow 1 => 2)`))
+		assert.Equal(t, sexpr.Symbol("no-arrow"), mustEval(t, `(arrow 1 2)`))
+	})
+
+	t.Run("shadowed literals do not match", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax choose
+				(syntax-rules (else)
+					((_ (else e)) e)
+					((_ (c e)) (if c e 'none))))
+		`)
+
+		// assert
+		assert.Equal(t, 1, mustEval(t, `(choose (else 1))`))
+		assert.Equal(t, sexpr.Symbol("none"), mustEval(t, `(let ((else #f)) (choose (else 1)))`))
+	})
+
+	t.Run("recursive macro", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax my-or
+				(syntax-rules ()
+					((_) #f)
+					((_ e) e)
+					((_ e r ...) (let ((t e)) (if t t (my-or r ...))))))
+		`)
+
+		// assert
+		assert.Equal(t, true, mustEval(t, `(let ((t #t)) (my-or #f #f t))`))
+		assert.Equal(t, false, mustEval(t, `(let ((t #f)) (my-or #f t))`))
+		assert.Equal(t, false, mustEval(t, `(my-or)`))
+	})
+
+	t.Run("custom ellipsis and escape", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax my-list
+				(syntax-rules ::: ()
+					((_ x :::) (list x :::))))
+		`)
+		mustEval(t, `
+			(define-syntax quote-ellipsis
+				(syntax-rules ()
+					((_ x) '(x (... ...)))))
+		`)
+
+		// assert
+		assert.Equal(t, sexpr.List(1, 2), mustEval(t, `(my-list 1 2)`))
+		assert.Equal(t, sexpr.List(1, sexpr.Symbol("...")), mustEval(t, `(quote-ellipsis 1)`))
+	})
+
+	t.Run("macro defining macro", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax define-constant-macro
+				(syntax-rules ()
+					((_ name value)
+						(define-syntax name
+							(syntax-rules ()
+								((_) value))))))
+		`)
+		mustEval(t, `(define-constant-macro forty-two-macro 42)`)
+
+		// assert
+		assert.Equal(t, 42, mustEval(t, `(forty-two-macro)`))
+	})
+
+	t.Run("let-syntax", func(t *testing.T) {
+		result := mustEval(t, `
+			(let ((x 'outer))
+				(let-syntax ((get-x (syntax-rules () ((_) x))))
+					(let ((x 'inner))
+						(get-x))))`)
+
+		assert.Equal(t, sexpr.Symbol("outer"), result)
+		assert.Equal(t, KindUnboundVariable, evalError(t, `(get-x)`).Kind)
+	})
+
+	t.Run("letrec-syntax", func(t *testing.T) {
+		result := mustEval(t, `
+			(letrec-syntax
+				((my-and (syntax-rules ()
+					((_) #t)
+					((_ e) e)
+					((_ e r ...) (if e (my-and r ...) #f)))))
+				(my-and #t #t 3))`)
+
+		assert.Equal(t, 3, result)
+	})
+
+	t.Run("vector patterns and templates", func(t *testing.T) {
+		// arrange
+		mustEval(t, `
+			(define-syntax vector-swap
+				(syntax-rules ()
+					((_ #(a b ...)) '#(b ... a))))
+		`)
+
+		// assert
+		assert.Equal(t, "#(2 3 1)", sexpr.Print(mustEval(t, `(vector-swap #(1 2 3))`)))
+		assert.Equal(t, KindSyntax, evalError(t, `(vector-swap (1 2 3))`).Kind)
+	})
+
+	t.Run("keyword is not an expression", func(t *testing.T) {
+		assert.Equal(t, KindSyntax, evalError(t, `my-or`).Kind)
+		assert.Equal(t, KindSyntax, evalError(t, `(define-syntax bad 42)`).Kind)
+	})
+}
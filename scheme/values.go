@@ -0,0 +1,49 @@
+package scheme
+
+import (
+	"strings"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+// Values are multiple values returned by (values ...) with other than one
+// argument, a single value is returned as is.
+type Values []sexpr.Expr
+
+func (values Values) String() string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = sexpr.Print(value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// valuesToSlice returns values of expression that may be multiple values.
+func valuesToSlice(value sexpr.Expr) []sexpr.Expr {
+	if values, ok := value.(Values); ok {
+		return values
+	}
+	return []sexpr.Expr{value}
+}
+
+func addValuesBuiltins(env *Environment) {
+	AddFuncToEnv(env, "values", valuesBuiltin)
+	addControlToEnv(env, "call-with-values", callWithValuesBuiltin)
+}
+
+func valuesBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	return Values(args), nil
+}
+
+func callWithValuesBuiltin(args []sexpr.Expr, k *Continuation, env *Environment) (sexpr.Expr, *tailCall, error) {
+	if err := checkArity("call-with-values", 2, args); err != nil {
+		return nil, nil, err
+	}
+	consumer := args[1]
+	return nil, &tailCall{Procedure: args[0], Env: env, Then: func(produced sexpr.Expr) (sexpr.Expr, *tailCall, error) {
+		return nil, &tailCall{Procedure: consumer, Arguments: valuesToSlice(produced), Env: env}, nil
+	}}, nil
+}
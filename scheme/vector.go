@@ -0,0 +1,564 @@
+package scheme
+
+import (
+	"unicode/utf8"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func addVectorBuiltins(env *Environment) {
+	AddFuncToEnv(env, "vector?", predicateBuiltin("vector?", func(e sexpr.Expr) bool {
+		_, ok := e.(*sexpr.Vector)
+		return ok
+	}))
+	AddFuncToEnv(env, "make-vector", makeVectorBuiltin)
+	AddFuncToEnv(env, "vector", vectorBuiltin)
+	AddFuncToEnv(env, "vector-length", vectorLengthBuiltin)
+	AddFuncToEnv(env, "vector-ref", vectorRefBuiltin)
+	AddFuncToEnv(env, "vector-set!", vectorSetBuiltin)
+	AddFuncToEnv(env, "vector->list", vectorToListBuiltin)
+	AddFuncToEnv(env, "list->vector", listToVectorBuiltin)
+	AddFuncToEnv(env, "vector->string", vectorToStringBuiltin)
+	AddFuncToEnv(env, "string->vector", stringToVectorBuiltin)
+	AddFuncToEnv(env, "vector-copy", vectorCopyBuiltin)
+	AddFuncToEnv(env, "vector-copy!", vectorCopyToBuiltin)
+	AddFuncToEnv(env, "vector-append", vectorAppendBuiltin)
+	AddFuncToEnv(env, "vector-fill!", vectorFillBuiltin)
+	AddFuncToEnv(env, "vector-map", vectorMapBuiltin("vector-map", true))
+	AddFuncToEnv(env, "vector-for-each", vectorMapBuiltin("vector-for-each", false))
+
+	AddFuncToEnv(env, "bytevector?", predicateBuiltin("bytevector?", func(e sexpr.Expr) bool {
+		_, ok := e.(*sexpr.Bytevector)
+		return ok
+	}))
+	AddFuncToEnv(env, "make-bytevector", makeBytevectorBuiltin)
+	AddFuncToEnv(env, "bytevector", bytevectorBuiltin)
+	AddFuncToEnv(env, "bytevector-length", bytevectorLengthBuiltin)
+	AddFuncToEnv(env, "bytevector-u8-ref", bytevectorRefBuiltin)
+	AddFuncToEnv(env, "bytevector-u8-set!", bytevectorSetBuiltin)
+	AddFuncToEnv(env, "bytevector-copy", bytevectorCopyBuiltin)
+	AddFuncToEnv(env, "bytevector-copy!", bytevectorCopyToBuiltin)
+	AddFuncToEnv(env, "bytevector-append", bytevectorAppendBuiltin)
+	AddFuncToEnv(env, "utf8->string", utf8ToStringBuiltin)
+	AddFuncToEnv(env, "string->utf8", stringToUTF8Builtin)
+}
+
+func vectorArg(procedure string, args []sexpr.Expr, position int) (*sexpr.Vector, error) {
+	vector, ok := args[position].(*sexpr.Vector)
+	if !ok {
+		return nil, errWrongType(args[position], position, procedure)
+	}
+	return vector, nil
+}
+
+func bytevectorArg(procedure string, args []sexpr.Expr, position int) (*sexpr.Bytevector, error) {
+	bytevector, ok := args[position].(*sexpr.Bytevector)
+	if !ok {
+		return nil, errWrongType(args[position], position, procedure)
+	}
+	return bytevector, nil
+}
+
+func byteArg(procedure string, args []sexpr.Expr, position int) (byte, error) {
+	b, ok := args[position].(int)
+	if !ok {
+		return 0, errWrongType(args[position], position, procedure)
+	}
+	if b < 0 || b > 255 {
+		return 0, errBadRange(args[position], position, procedure)
+	}
+	return byte(b), nil
+}
+
+// indexArg returns index argument which must be less than length, or not
+// greater than length when inclusive is true.
+func indexArg(procedure string, args []sexpr.Expr, position int, length int, inclusive bool) (int, error) {
+	index, ok := args[position].(int)
+	if !ok {
+		return 0, errWrongType(args[position], position, procedure)
+	}
+	if index < 0 || index > length || index == length && !inclusive {
+		return 0, errBadRange(args[position], position, procedure)
+	}
+	return index, nil
+}
+
+// rangeArgs returns optional start and end arguments at position, they
+// default to the whole sequence of length.
+func rangeArgs(procedure string, args []sexpr.Expr, position int, length int) (start int, end int, err error) {
+	start, end = 0, length
+	if len(args) > position {
+		start, err = indexArg(procedure, args, position, length, true)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(args) > position+1 {
+		end, err = indexArg(procedure, args, position+1, length, true)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if start > end {
+		return 0, 0, errBadRange(args[position], position, procedure)
+	}
+	return start, end, nil
+}
+
+// maxLength limits size of vectors made at once.
+const maxLength = 1 << 24
+
+// allocate counts elements of vector which is made by builtin against limits
+// of interpreter.
+func allocate(env *Environment, elements int) error {
+	if env.interpreter == nil {
+		return nil
+	}
+	return env.interpreter.allocate(elements)
+}
+
+func makeVectorBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("make-vector", 1, 2, args); err != nil {
+		return nil, err
+	}
+	length, err := indexArg("make-vector", args, 0, maxLength, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := allocate(env, length); err != nil {
+		return nil, err
+	}
+	var fill sexpr.Expr = false
+	if len(args) == 2 {
+		fill = args[1]
+	}
+	elements := make([]sexpr.Expr, length)
+	for i := range elements {
+		elements[i] = fill
+	}
+	return &sexpr.Vector{Elements: elements}, nil
+}
+
+func vectorBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	elements := make([]sexpr.Expr, len(args))
+	copy(elements, args)
+	return &sexpr.Vector{Elements: elements}, nil
+}
+
+func vectorLengthBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("vector-length", 1, args); err != nil {
+		return nil, err
+	}
+	vector, err := vectorArg("vector-length", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return len(vector.Elements), nil
+}
+
+func vectorRefBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("vector-ref", 2, args); err != nil {
+		return nil, err
+	}
+	vector, err := vectorArg("vector-ref", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	index, err := indexArg("vector-ref", args, 1, len(vector.Elements), false)
+	if err != nil {
+		return nil, err
+	}
+	return vector.Elements[index], nil
+}
+
+func vectorSetBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("vector-set!", 3, args); err != nil {
+		return nil, err
+	}
+	vector, err := vectorArg("vector-set!", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	index, err := indexArg("vector-set!", args, 1, len(vector.Elements), false)
+	if err != nil {
+		return nil, err
+	}
+	vector.Elements[index] = args[2]
+	return nil, nil
+}
+
+func vectorToListBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("vector->list", 1, 3, args); err != nil {
+		return nil, err
+	}
+	vector, err := vectorArg("vector->list", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rangeArgs("vector->list", args, 1, len(vector.Elements))
+	if err != nil {
+		return nil, err
+	}
+	return sexpr.List(vector.Elements[start:end]...), nil
+}
+
+func listToVectorBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("list->vector", 1, args); err != nil {
+		return nil, err
+	}
+	elements, ok := sexpr.ToSlice(args[0])
+	if !ok {
+		return nil, errWrongType(args[0], 0, "list->vector")
+	}
+	return &sexpr.Vector{Elements: elements}, nil
+}
+
+func vectorToStringBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("vector->string", 1, 3, args); err != nil {
+		return nil, err
+	}
+	vector, err := vectorArg("vector->string", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rangeArgs("vector->string", args, 1, len(vector.Elements))
+	if err != nil {
+		return nil, err
+	}
+	runes := make([]rune, 0, end-start)
+	for _, element := range vector.Elements[start:end] {
+		c, ok := element.(sexpr.Char)
+		if !ok {
+			return nil, errWrongType(vector, 0, "vector->string")
+		}
+		runes = append(runes, rune(c))
+	}
+	return string(runes), nil
+}
+
+func stringToVectorBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("string->vector", 1, 3, args); err != nil {
+		return nil, err
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "string->vector")
+	}
+	runes := []rune(s)
+	start, end, err := rangeArgs("string->vector", args, 1, len(runes))
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]sexpr.Expr, 0, end-start)
+	for _, r := range runes[start:end] {
+		elements = append(elements, sexpr.Char(r))
+	}
+	return &sexpr.Vector{Elements: elements}, nil
+}
+
+func vectorCopyBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("vector-copy", 1, 3, args); err != nil {
+		return nil, err
+	}
+	vector, err := vectorArg("vector-copy", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rangeArgs("vector-copy", args, 1, len(vector.Elements))
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]sexpr.Expr, end-start)
+	copy(elements, vector.Elements[start:end])
+	return &sexpr.Vector{Elements: elements}, nil
+}
+
+// vectorCopyToBuiltin is (vector-copy! to at from [start [end]]), overlapping
+// ranges of the same vector are copied correctly.
+func vectorCopyToBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("vector-copy!", 3, 5, args); err != nil {
+		return nil, err
+	}
+	to, err := vectorArg("vector-copy!", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	at, err := indexArg("vector-copy!", args, 1, len(to.Elements), true)
+	if err != nil {
+		return nil, err
+	}
+	from, err := vectorArg("vector-copy!", args, 2)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rangeArgs("vector-copy!", args, 3, len(from.Elements))
+	if err != nil {
+		return nil, err
+	}
+	if end-start > len(to.Elements)-at {
+		return nil, errBadRange(args[2], 2, "vector-copy!")
+	}
+	copy(to.Elements[at:], from.Elements[start:end])
+	return nil, nil
+}
+
+func vectorAppendBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	length := 0
+	for i := range args {
+		vector, err := vectorArg("vector-append", args, i)
+		if err != nil {
+			return nil, err
+		}
+		length += len(vector.Elements)
+	}
+	if err := allocate(env, length); err != nil {
+		return nil, err
+	}
+	elements := make([]sexpr.Expr, 0, length)
+	for _, vector := range args {
+		elements = append(elements, vector.(*sexpr.Vector).Elements...)
+	}
+	return &sexpr.Vector{Elements: elements}, nil
+}
+
+func vectorFillBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("vector-fill!", 2, 4, args); err != nil {
+		return nil, err
+	}
+	vector, err := vectorArg("vector-fill!", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rangeArgs("vector-fill!", args, 2, len(vector.Elements))
+	if err != nil {
+		return nil, err
+	}
+	for i := start; i < end; i++ {
+		vector.Elements[i] = args[1]
+	}
+	return nil, nil
+}
+
+// vectorMapBuiltin makes vector-map or vector-for-each which apply procedure
+// to elements of vectors up to the length of the shortest one.
+func vectorMapBuiltin(name string, collect bool) Procedure {
+	return func(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+		if err := checkMinArity(name, 2, args); err != nil {
+			return nil, err
+		}
+		length := -1
+		vectors := make([]*sexpr.Vector, len(args)-1)
+		for i := range vectors {
+			vector, err := vectorArg(name, args, i+1)
+			if err != nil {
+				return nil, err
+			}
+			if length < 0 || len(vector.Elements) < length {
+				length = len(vector.Elements)
+			}
+			vectors[i] = vector
+		}
+
+		var results []sexpr.Expr
+		if collect {
+			results = make([]sexpr.Expr, length)
+		}
+		for i := 0; i < length; i++ {
+			arguments := make([]sexpr.Expr, len(vectors))
+			for j, vector := range vectors {
+				arguments[j] = vector.Elements[i]
+			}
+			result, err := applyProcedure(args[0], arguments, env)
+			if err != nil {
+				return nil, err
+			}
+			if collect {
+				results[i] = result
+			}
+		}
+		if !collect {
+			return nil, nil
+		}
+		return &sexpr.Vector{Elements: results}, nil
+	}
+}
+
+func makeBytevectorBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("make-bytevector", 1, 2, args); err != nil {
+		return nil, err
+	}
+	length, err := indexArg("make-bytevector", args, 0, maxLength, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := allocate(env, length); err != nil {
+		return nil, err
+	}
+	var fill byte
+	if len(args) == 2 {
+		fill, err = byteArg("make-bytevector", args, 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bytes := make([]byte, length)
+	for i := range bytes {
+		bytes[i] = fill
+	}
+	return &sexpr.Bytevector{Bytes: bytes}, nil
+}
+
+func bytevectorBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	bytes := make([]byte, len(args))
+	for i := range args {
+		b, err := byteArg("bytevector", args, i)
+		if err != nil {
+			return nil, err
+		}
+		bytes[i] = b
+	}
+	return &sexpr.Bytevector{Bytes: bytes}, nil
+}
+
+func bytevectorLengthBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("bytevector-length", 1, args); err != nil {
+		return nil, err
+	}
+	bytevector, err := bytevectorArg("bytevector-length", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return len(bytevector.Bytes), nil
+}
+
+func bytevectorRefBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("bytevector-u8-ref", 2, args); err != nil {
+		return nil, err
+	}
+	bytevector, err := bytevectorArg("bytevector-u8-ref", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	index, err := indexArg("bytevector-u8-ref", args, 1, len(bytevector.Bytes), false)
+	if err != nil {
+		return nil, err
+	}
+	return int(bytevector.Bytes[index]), nil
+}
+
+func bytevectorSetBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArity("bytevector-u8-set!", 3, args); err != nil {
+		return nil, err
+	}
+	bytevector, err := bytevectorArg("bytevector-u8-set!", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	index, err := indexArg("bytevector-u8-set!", args, 1, len(bytevector.Bytes), false)
+	if err != nil {
+		return nil, err
+	}
+	b, err := byteArg("bytevector-u8-set!", args, 2)
+	if err != nil {
+		return nil, err
+	}
+	bytevector.Bytes[index] = b
+	return nil, nil
+}
+
+func bytevectorCopyBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("bytevector-copy", 1, 3, args); err != nil {
+		return nil, err
+	}
+	bytevector, err := bytevectorArg("bytevector-copy", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rangeArgs("bytevector-copy", args, 1, len(bytevector.Bytes))
+	if err != nil {
+		return nil, err
+	}
+	bytes := make([]byte, end-start)
+	copy(bytes, bytevector.Bytes[start:end])
+	return &sexpr.Bytevector{Bytes: bytes}, nil
+}
+
+func bytevectorCopyToBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("bytevector-copy!", 3, 5, args); err != nil {
+		return nil, err
+	}
+	to, err := bytevectorArg("bytevector-copy!", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	at, err := indexArg("bytevector-copy!", args, 1, len(to.Bytes), true)
+	if err != nil {
+		return nil, err
+	}
+	from, err := bytevectorArg("bytevector-copy!", args, 2)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rangeArgs("bytevector-copy!", args, 3, len(from.Bytes))
+	if err != nil {
+		return nil, err
+	}
+	if end-start > len(to.Bytes)-at {
+		return nil, errBadRange(args[2], 2, "bytevector-copy!")
+	}
+	copy(to.Bytes[at:], from.Bytes[start:end])
+	return nil, nil
+}
+
+func bytevectorAppendBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	length := 0
+	for i := range args {
+		bytevector, err := bytevectorArg("bytevector-append", args, i)
+		if err != nil {
+			return nil, err
+		}
+		length += len(bytevector.Bytes)
+	}
+	if err := allocate(env, length); err != nil {
+		return nil, err
+	}
+	bytes := make([]byte, 0, length)
+	for _, bytevector := range args {
+		bytes = append(bytes, bytevector.(*sexpr.Bytevector).Bytes...)
+	}
+	return &sexpr.Bytevector{Bytes: bytes}, nil
+}
+
+func utf8ToStringBuiltin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("utf8->string", 1, 3, args); err != nil {
+		return nil, err
+	}
+	bytevector, err := bytevectorArg("utf8->string", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rangeArgs("utf8->string", args, 1, len(bytevector.Bytes))
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(bytevector.Bytes[start:end]) {
+		return nil, errBadRange(args[0], 0, "utf8->string")
+	}
+	return string(bytevector.Bytes[start:end]), nil
+}
+
+func stringToUTF8Builtin(args []sexpr.Expr, env *Environment) (sexpr.Expr, error) {
+	if err := checkArityRange("string->utf8", 1, 3, args); err != nil {
+		return nil, err
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, errWrongType(args[0], 0, "string->utf8")
+	}
+	runes := []rune(s)
+	start, end, err := rangeArgs("string->utf8", args, 1, len(runes))
+	if err != nil {
+		return nil, err
+	}
+	return &sexpr.Bytevector{Bytes: []byte(string(runes[start:end]))}, nil
+}
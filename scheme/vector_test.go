@@ -0,0 +1,86 @@
+package scheme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adzeitor/goscheme/sexpr"
+)
+
+func TestVectors(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: `#(1 "two" #\3)`, want: `#(1 "two" #\3)`},
+		{in: `(vector? #(1))`, want: `#t`},
+		{in: `(vector? '(1))`, want: `#f`},
+		{in: `(make-vector 3 'a)`, want: `#(a a a)`},
+		{in: `(vector 1 (+ 1 1))`, want: `#(1 2)`},
+		{in: `(vector-length #())`, want: `0`},
+		{in: `(vector-ref #(a b c) 1)`, want: `b`},
+		{in: `(let ((v (vector 1 2 3))) (vector-set! v 0 'x) v)`, want: `#(x 2 3)`},
+		{in: `(vector->list #(1 2 3 4) 1)`, want: `(2 3 4)`},
+		{in: `(vector->list #(1 2 3 4) 1 3)`, want: `(2 3)`},
+		{in: `(list->vector '(1 2))`, want: `#(1 2)`},
+		{in: `(vector->string #(#\a #\b))`, want: `"ab"`},
+		{in: `(string->vector "aλ")`, want: `#(#\a #\λ)`},
+		{in: `(vector-copy #(1 2 3) 1)`, want: `#(2 3)`},
+		{in: `(let ((v (vector 1 2 3 4 5))) (vector-copy! v 1 v 0 3) v)`, want: `#(1 1 2 3 5)`},
+		{in: `(vector-append #(1) #() #(2 3))`, want: `#(1 2 3)`},
+		{in: `(let ((v (vector 1 2 3))) (vector-fill! v 0 1) v)`, want: `#(1 0 0)`},
+		{in: `(vector-map + #(1 2 3) #(10 20))`, want: `#(11 22)`},
+		{in: `(let ((sum 0)) (vector-for-each (lambda (x) (set! sum (+ sum x))) #(1 2 3)) sum)`, want: `6`},
+		{in: `(equal? #(1 (2)) (vector 1 (list 2)))`, want: `#t`},
+		{in: `(let ((v #(1))) (eqv? v v))`, want: `#t`},
+		{in: `(eqv? (vector) (vector))`, want: `#f`},
+
+		{in: `#u8(0 255)`, want: `#u8(0 255)`},
+		{in: `(bytevector? #u8())`, want: `#t`},
+		{in: `(make-bytevector 2 7)`, want: `#u8(7 7)`},
+		{in: `(bytevector 1 2)`, want: `#u8(1 2)`},
+		{in: `(bytevector-length #u8(1 2 3))`, want: `3`},
+		{in: `(bytevector-u8-ref #u8(5 6 7) 2)`, want: `7`},
+		{in: `(let ((b (bytevector 1 2))) (bytevector-u8-set! b 1 255) b)`, want: `#u8(1 255)`},
+		{in: `(bytevector-copy #u8(1 2 3) 1 2)`, want: `#u8(2)`},
+		{in: `(let ((b (make-bytevector 3 0))) (bytevector-copy! b 1 #u8(8 9)) b)`, want: `#u8(0 8 9)`},
+		{in: `(bytevector-append #u8(1) #u8(2))`, want: `#u8(1 2)`},
+		{in: `(utf8->string #u8(206 187 33))`, want: `"λ!"`},
+		{in: `(string->utf8 "aλ")`, want: `#u8(97 206 187)`},
+		{in: `(equal? #u8(1 2) (bytevector 1 2))`, want: `#t`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, sexpr.Print(mustEval(t, tt.in)))
+		})
+	}
+}
+
+func TestVectorErrors(t *testing.T) {
+	cases := []struct {
+		in   string
+		kind ErrorKind
+	}{
+		{in: `(vector-ref #(1 2) 2)`, kind: KindBadRange},
+		{in: `(vector-ref #(1 2) -1)`, kind: KindBadRange},
+		{in: `(vector-ref '(1 2) 0)`, kind: KindWrongType},
+		{in: `(vector-set! #(1) 'a 0)`, kind: KindWrongType},
+		{in: `(vector->list #(1 2) 2 1)`, kind: KindBadRange},
+		{in: `(vector-copy! (vector 1) 0 #(1 2))`, kind: KindBadRange},
+		{in: `(vector->string #(1))`, kind: KindWrongType},
+		{in: `(make-vector -1)`, kind: KindBadRange},
+		{in: `(make-vector 268435455)`, kind: KindBadRange},
+		{in: `(make-bytevector 268435455)`, kind: KindBadRange},
+		{in: `(bytevector 256)`, kind: KindBadRange},
+		{in: `(bytevector-u8-ref #u8(1) 1)`, kind: KindBadRange},
+		{in: `(utf8->string #u8(255))`, kind: KindBadRange},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.kind, evalError(t, tt.in).Kind)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package sexpr
+
+import (
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Char is a character, it is read as #\a, #\space or #\x3bb.
+type Char rune
+
+var charNames = map[string]Char{
+	"alarm":     '\a',
+	"backspace": '\b',
+	"delete":    0x7f,
+	"escape":    0x1b,
+	"newline":   '\n',
+	"null":      0,
+	"return":    '\r',
+	"space":     ' ',
+	"tab":       '\t',
+}
+
+// namesOfChars is the inverse of charNames used for printing.
+var namesOfChars = func() map[Char]string {
+	names := make(map[Char]string, len(charNames))
+	for name, c := range charNames {
+		names[c] = name
+	}
+	return names
+}()
+
+// lexChar lexes character at the beginning of s which starts with #\.
+func (p *parser) lexChar(s string) (Char, error) {
+	start := p.offset
+	// the first character is taken even if it is a delimiter like #\(
+	first, size := utf8.DecodeRuneInString(s[2:])
+	if size == 0 {
+		return 0, p.incompleteAt(start, "unexpected end of input")
+	}
+	end := 2 + size
+	end += tokenEnd(s[end:])
+	token := s[2:end]
+	p.offset += end
+	if len(token) == size {
+		return Char(first), nil
+	}
+	if c, ok := charNames[token]; ok {
+		return c, nil
+	}
+	if token[0] == 'x' || token[0] == 'X' {
+		code, err := strconv.ParseUint(token[1:], 16, 32)
+		if err == nil && utf8.ValidRune(rune(code)) {
+			return Char(code), nil
+		}
+	}
+	return 0, p.errorAt(start, "unknown character name %q", token)
+}
+
+func printChar(c Char) string {
+	if name, ok := namesOfChars[c]; ok {
+		return `#\` + name
+	}
+	if !unicode.IsPrint(rune(c)) {
+		return `#\x` + strconv.FormatInt(int64(c), 16)
+	}
+	return `#\` + string(c)
+}
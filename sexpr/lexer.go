@@ -0,0 +1,356 @@
+package sexpr
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	// tokenEOF is the end of input.
+	tokenEOF tokenKind = iota
+	// tokenOpen is an opening paren of list, vector or bytevector.
+	tokenOpen
+	tokenClose
+	// tokenDot separates dotted tail of list.
+	tokenDot
+	// tokenQuote is an abbreviation like ' or ,@.
+	tokenQuote
+	// tokenDatumComment is #; which comments out the next datum.
+	tokenDatumComment
+	// tokenAtom is a datum without parts like number, string or symbol.
+	tokenAtom
+)
+
+type token struct {
+	kind tokenKind
+	// start and end are byte offsets of the token in the source text.
+	start int
+	end   int
+	// text is the opening of tokenOpen or the prefix of tokenQuote.
+	text string
+	// value is the datum of tokenAtom.
+	value Expr
+}
+
+// lex returns the next token skipping whitespace and comments except datum
+// ones. Every byte of input is examined once.
+func (p *parser) lex() (token, error) {
+	if err := p.skipSpace(); err != nil {
+		return token{}, err
+	}
+	start := p.offset
+	s := p.src[start:]
+	if s == "" {
+		return token{kind: tokenEOF, start: start, end: start}, nil
+	}
+
+	switch s[0] {
+	case '(':
+		return p.punctuation(tokenOpen, "("), nil
+	case ')':
+		return p.punctuation(tokenClose, ")"), nil
+	case '\'', '`':
+		return p.punctuation(tokenQuote, s[:1]), nil
+	case ',':
+		if strings.HasPrefix(s, ",@") {
+			return p.punctuation(tokenQuote, ",@"), nil
+		}
+		return p.punctuation(tokenQuote, ","), nil
+	case '"':
+		text, err := p.lexQuoted('"', "string")
+		return p.atom(start, text), err
+	case '|':
+		name, err := p.lexQuoted('|', "symbol")
+		return p.atom(start, Symbol(name)), err
+	case '#':
+		return p.lexHash(s)
+	case '.':
+		if isDelimiter(s[1:]) {
+			return p.punctuation(tokenDot, "."), nil
+		}
+	}
+	return p.lexAtom(s)
+}
+
+func (p *parser) punctuation(kind tokenKind, text string) token {
+	start := p.offset
+	p.offset += len(text)
+	return token{kind: kind, start: start, end: p.offset, text: text}
+}
+
+// atom makes token of datum which ends at the current offset.
+func (p *parser) atom(start int, value Expr) token {
+	return token{kind: tokenAtom, start: start, end: p.offset, value: value}
+}
+
+// skipSpace skips whitespace, line comments after `;` and nested block
+// comments #| ... |#.
+func (p *parser) skipSpace() error {
+	for p.offset < len(p.src) {
+		switch c := p.src[p.offset]; {
+		case c == ' ' || c == '\n' || c == '\t' || c == '\r' || c == '\f':
+			p.offset++
+		case c == ';':
+			end := strings.IndexByte(p.src[p.offset:], '\n')
+			if end < 0 {
+				p.offset = len(p.src)
+				return nil
+			}
+			p.offset += end + 1
+		case strings.HasPrefix(p.src[p.offset:], "#|"):
+			end := blockCommentEnd(p.src[p.offset:])
+			if end < 0 {
+				return p.incompleteAt(p.offset, "unterminated block comment")
+			}
+			p.offset += end
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// blockCommentEnd returns length of block comment at the beginning of s
+// including nested ones, or -1 if it is not terminated.
+func blockCommentEnd(s string) int {
+	depth := 0
+	for i := 0; i+1 < len(s); i++ {
+		switch s[i : i+2] {
+		case "#|":
+			depth++
+			i++
+		case "|#":
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// lexHash lexes tokens starting with #: vector and bytevector openings,
+// datum comments, characters, booleans, special names like #!optional and
+// numbers with prefixes like #xFF.
+func (p *parser) lexHash(s string) (token, error) {
+	start := p.offset
+	switch {
+	case strings.HasPrefix(s, "#("):
+		return p.punctuation(tokenOpen, "#("), nil
+	case strings.HasPrefix(s, "#u8("):
+		return p.punctuation(tokenOpen, "#u8("), nil
+	case strings.HasPrefix(s, "#;"):
+		return p.punctuation(tokenDatumComment, "#;"), nil
+	case strings.HasPrefix(s, `#\`):
+		c, err := p.lexChar(s)
+		return p.atom(start, c), err
+	}
+	if name := hashBangName(s); name != "" {
+		p.offset += len(name)
+		return p.atom(start, Symbol(name)), nil
+	}
+
+	end := tokenEnd(s)
+	var value Expr
+	switch s[:end] {
+	case "#t", "#true":
+		value = true
+	case "#f", "#false":
+		value = false
+	default:
+		n, ok := ParseNumber(s[:end], 10)
+		if !ok {
+			return token{}, p.unexpected(start)
+		}
+		value = n
+	}
+	p.offset += end
+	return p.atom(start, value), nil
+}
+
+// hashBangName returns special name like #!optional at the beginning of s,
+// such names are read as symbols.
+func hashBangName(s string) string {
+	if !strings.HasPrefix(s, "#!") {
+		return ""
+	}
+	end := 2
+	for end < len(s) && ('a' <= s[end] && s[end] <= 'z' || s[end] == '-') {
+		end++
+	}
+	if end == 2 {
+		return ""
+	}
+	return s[:end]
+}
+
+// lexAtom lexes number or identifier.
+func (p *parser) lexAtom(s string) (token, error) {
+	start := p.offset
+	if c := s[0]; '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.' {
+		end := tokenEnd(s)
+		if n, ok := parseSmallInt(s[:end]); ok {
+			p.offset += end
+			return p.atom(start, n), nil
+		}
+		if n, ok := ParseNumber(s[:end], 10); ok {
+			p.offset += end
+			return p.atom(start, n), nil
+		}
+	}
+
+	// identifier ends at any character which may not occur in it, like quote
+	end := 0
+	for end < len(s) {
+		r, size := rune(s[end]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRuneInString(s[end:])
+		}
+		if !isSubsequent(r) {
+			break
+		}
+		end += size
+	}
+	if !isIdentifier(s[:end]) {
+		return token{}, p.unexpected(start)
+	}
+	p.offset += end
+	return p.atom(start, Symbol(s[:end])), nil
+}
+
+// parseSmallInt parses decimal integer which surely fits into int, it is a
+// shortcut for the most common numbers.
+func parseSmallInt(s string) (int, bool) {
+	digits := s
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		digits = s[1:]
+	}
+	if digits == "" || len(digits) > 18 {
+		return 0, false
+	}
+	n := 0
+	for i := 0; i < len(digits); i++ {
+		if !isDigit(digits[i]) {
+			return 0, false
+		}
+		n = n*10 + int(digits[i]-'0')
+	}
+	if s[0] == '-' {
+		n = -n
+	}
+	return n, true
+}
+
+// tokenEnd returns length of the token at the beginning of s, token ends at
+// delimiter.
+func tokenEnd(s string) int {
+	end := 0
+	for !isDelimiter(s[end:]) {
+		_, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+	}
+	return end
+}
+
+// isDelimiter reports whether s is empty or starts with a character which
+// terminates identifiers and numbers.
+func isDelimiter(s string) bool {
+	if s == "" {
+		return true
+	}
+	switch s[0] {
+	case ' ', '\n', '\t', '\r', '\f', '(', ')', '"', ';', '|':
+		return true
+	}
+	return false
+}
+
+// lexQuoted lexes text between quote characters, backslash escapes are
+// allowed inside. It is used for strings and |symbols|, name of them is used
+// in errors.
+func (p *parser) lexQuoted(quote byte, name string) (string, error) {
+	start := p.offset
+	stops := string(quote) + `\`
+	p.offset++
+	end := strings.IndexAny(p.src[p.offset:], stops)
+	if end >= 0 && p.src[p.offset+end] == quote {
+		text := p.src[p.offset : p.offset+end]
+		p.offset += end + 1
+		return text, nil
+	}
+
+	var b strings.Builder
+	for end >= 0 {
+		b.WriteString(p.src[p.offset : p.offset+end])
+		p.offset += end
+		if p.src[p.offset] == quote {
+			p.offset++
+			return b.String(), nil
+		}
+		if p.offset+1 == len(p.src) {
+			break
+		}
+		if err := p.lexEscape(&b); err != nil {
+			return "", err
+		}
+		end = strings.IndexAny(p.src[p.offset:], stops)
+	}
+	return "", p.incompleteAt(start, "unterminated %s", name)
+}
+
+var escapes = map[byte]byte{
+	'a':  '\a',
+	'b':  '\b',
+	't':  '\t',
+	'n':  '\n',
+	'r':  '\r',
+	'"':  '"',
+	'\\': '\\',
+	'|':  '|',
+}
+
+// lexEscape lexes escape sequence which starts with backslash at the current
+// offset and writes the character it denotes into value. The backslash is not
+// the last character of input.
+func (p *parser) lexEscape(value *strings.Builder) error {
+	start := p.offset
+	s := p.src[start:]
+	if c, ok := escapes[s[1]]; ok {
+		value.WriteByte(c)
+		p.offset += 2
+		return nil
+	}
+	switch s[1] {
+	case 'x', 'X':
+		end := strings.IndexAny(s, `;"|`)
+		if end < 0 {
+			return p.incompleteAt(start, "unterminated hex escape")
+		}
+		if s[end] != ';' {
+			return p.errorAt(start, "unterminated hex escape")
+		}
+		code, err := strconv.ParseUint(s[2:end], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorAt(start, "invalid hex escape %q", s[:end+1])
+		}
+		value.WriteRune(rune(code))
+		p.offset += end + 1
+		return nil
+	}
+
+	// line continuation: \<intraline whitespace>*<newline><intraline whitespace>*
+	remains := strings.TrimLeft(s[1:], " \t")
+	if strings.HasPrefix(remains, "\r\n") {
+		remains = remains[1:]
+	}
+	if !strings.HasPrefix(remains, "\n") {
+		return p.errorAt(start, "invalid escape %q", s[:2])
+	}
+	remains = strings.TrimLeft(remains[1:], " \t")
+	p.offset = len(p.src) - len(remains)
+	return nil
+}
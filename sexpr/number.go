@@ -0,0 +1,273 @@
+package sexpr
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Numbers are represented by several Go types:
+//   - int is an exact integer which fits into machine word;
+//   - *big.Int is an exact integer which does not fit into int;
+//   - *big.Rat is an exact rational which is not an integer;
+//   - float64 is an inexact real.
+//
+// Integer and Rational keep exact numbers in this canonical form, so equal
+// numbers always have the same type.
+
+// Integer returns n as int if it fits, otherwise n itself.
+func Integer(n *big.Int) Expr {
+	if n.IsInt64() && int64(int(n.Int64())) == n.Int64() {
+		return int(n.Int64())
+	}
+	return n
+}
+
+// Rational returns r as integer if its denominator is 1, otherwise r itself.
+func Rational(r *big.Rat) Expr {
+	if r.IsInt() {
+		return Integer(new(big.Int).Set(r.Num()))
+	}
+	return r
+}
+
+// IsNumber reports whether e is a number.
+func IsNumber(e Expr) bool {
+	switch e.(type) {
+	case int, *big.Int, *big.Rat, float64:
+		return true
+	}
+	return false
+}
+
+var radixes = map[byte]int{'x': 16, 'd': 10, 'o': 8, 'b': 2}
+
+// ParseNumber parses number in Scheme syntax, for example 42, -1/3, 1.5e10,
+// #xFF, #e1.5 or +inf.0. Digits are read in radix unless the prefix says
+// otherwise.
+func ParseNumber(s string, radix int) (value Expr, ok bool) {
+	exactness := byte(0)
+	radixSet := false
+	for len(s) >= 2 && s[0] == '#' {
+		prefix := s[1] | 0x20 // to lower case
+		switch prefix {
+		case 'e', 'i':
+			if exactness != 0 {
+				return nil, false
+			}
+			exactness = prefix
+		case 'x', 'd', 'o', 'b':
+			if radixSet {
+				return nil, false
+			}
+			radixSet = true
+			radix = radixes[prefix]
+		default:
+			return nil, false
+		}
+		s = s[2:]
+	}
+
+	switch strings.ToLower(s) {
+	case "+inf.0":
+		value = math.Inf(1)
+	case "-inf.0":
+		value = math.Inf(-1)
+	case "+nan.0", "-nan.0":
+		value = math.NaN()
+	default:
+		value, ok = parseReal(s, radix, exactness == 'e')
+		if !ok {
+			return nil, false
+		}
+	}
+
+	switch exactness {
+	case 'e':
+		if f, isFloat := value.(float64); isFloat {
+			if math.IsInf(f, 0) || math.IsNaN(f) {
+				return nil, false
+			}
+			return Rational(new(big.Rat).SetFloat64(f)), true
+		}
+	case 'i':
+		return toFloat(value), true
+	}
+	return value, true
+}
+
+// parseReal parses number without prefixes. Decimal fractions are read as
+// exact when exact is set, so #e0.1 is 1/10 and not the closest float.
+func parseReal(s string, radix int, exact bool) (Expr, bool) {
+	if slash := strings.IndexByte(s, '/'); slash >= 0 {
+		numerator, ok := parseInteger(s[:slash], radix)
+		if !ok {
+			return nil, false
+		}
+		denominator, ok := parseInteger(s[slash+1:], radix)
+		if !ok || denominator.Sign() <= 0 || strings.ContainsAny(s[slash+1:], "+-") {
+			return nil, false
+		}
+		return Rational(new(big.Rat).SetFrac(numerator, denominator)), true
+	}
+	if n, ok := parseInteger(s, radix); ok {
+		return Integer(n), true
+	}
+	if radix != 10 || !isDecimal(s) {
+		return nil, false
+	}
+	if exact {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, false
+		}
+		return Rational(r), true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !isRangeError(err) {
+		return nil, false
+	}
+	return f, true
+}
+
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+// parseInteger parses optionally signed integer of digits in radix.
+func parseInteger(s string, radix int) (*big.Int, bool) {
+	digits := strings.TrimLeft(s, "+-")
+	if digits == "" || len(s)-len(digits) > 1 {
+		return nil, false
+	}
+	for _, c := range digits {
+		if digitValue(c) >= radix {
+			return nil, false
+		}
+	}
+	return new(big.Int).SetString(s, radix)
+}
+
+func digitValue(c rune) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'z':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return math.MaxInt32
+}
+
+// isDecimal checks syntax [sign] digits [. digits] [e [sign] digits] where
+// at least one digit of mantissa is present.
+func isDecimal(s string) bool {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	mantissaDigits := 0
+	for ; i < len(s) && isDigit(s[i]); i++ {
+		mantissaDigits++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for ; i < len(s) && isDigit(s[i]); i++ {
+			mantissaDigits++
+		}
+	}
+	if mantissaDigits == 0 {
+		return false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		exponentDigits := 0
+		for ; i < len(s) && isDigit(s[i]); i++ {
+			exponentDigits++
+		}
+		if exponentDigits == 0 {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func toFloat(n Expr) float64 {
+	switch value := n.(type) {
+	case int:
+		return float64(value)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(value).Float64()
+		return f
+	case *big.Rat:
+		f, _ := value.Float64()
+		return f
+	case float64:
+		return value
+	}
+	return math.NaN()
+}
+
+// FormatNumber prints number in radix, inexact numbers can be printed only in
+// radix 10.
+func FormatNumber(n Expr, radix int) string {
+	switch value := n.(type) {
+	case int:
+		return strconv.FormatInt(int64(value), radix)
+	case *big.Int:
+		return value.Text(radix)
+	case *big.Rat:
+		return value.Num().Text(radix) + "/" + value.Denom().Text(radix)
+	case float64:
+		return formatFloat(value)
+	}
+	return ""
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+inf.0"
+	case math.IsInf(f, -1):
+		return "-inf.0"
+	case math.IsNaN(f):
+		return "+nan.0"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if e := strings.IndexByte(s, 'e'); e >= 0 {
+		return s[:e] + "e" + strings.TrimPrefix(s[e+1:], "+")
+	}
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// numbersEqual compares numbers with the same exactness.
+func numbersEqual(a Expr, b Expr) bool {
+	switch value := a.(type) {
+	case int:
+		other, ok := b.(int)
+		return ok && value == other
+	case *big.Int:
+		other, ok := b.(*big.Int)
+		return ok && value.Cmp(other) == 0
+	case *big.Rat:
+		other, ok := b.(*big.Rat)
+		return ok && value.Cmp(other) == 0
+	case float64:
+		other, ok := b.(float64)
+		return ok && value == other
+	}
+	return false
+}
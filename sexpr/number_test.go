@@ -0,0 +1,97 @@
+package sexpr
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	bigInt, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	cases := []struct {
+		in   string
+		want Expr
+	}{
+		{in: `0`, want: 0},
+		{in: `-17`, want: -17},
+		{in: `123456789012345678901234567890`, want: bigInt},
+		{in: `1/3`, want: big.NewRat(1, 3)},
+		{in: `-6/4`, want: big.NewRat(-3, 2)},
+		{in: `6/3`, want: 2},
+		{in: `1.5`, want: 1.5},
+		{in: `.5`, want: 0.5},
+		{in: `-5.`, want: -5.0},
+		{in: `1e3`, want: 1000.0},
+		{in: `1.5E-2`, want: 0.015},
+		{in: `#e1.5`, want: big.NewRat(3, 2)},
+		{in: `#e0.1`, want: big.NewRat(1, 10)},
+		{in: `#e1e3`, want: 1000},
+		{in: `#i1/4`, want: 0.25},
+		{in: `#i3`, want: 3.0},
+		{in: `#xff`, want: 255},
+		{in: `#x-1A`, want: -26},
+		{in: `#b101`, want: 5},
+		{in: `#o17`, want: 15},
+		{in: `#e#x10`, want: 16},
+		{in: `#x#i10`, want: 16.0},
+		{in: `+inf.0`, want: math.Inf(1)},
+		{in: `-inf.0`, want: math.Inf(-1)},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := ParseNumber(tt.in, 10)
+			assert(t, true, ok)
+			assert(t, tt.want, got)
+		})
+	}
+
+	t.Run("nan", func(t *testing.T) {
+		got, ok := ParseNumber("+nan.0", 10)
+		assert(t, true, ok)
+		assert(t, true, math.IsNaN(got.(float64)))
+	})
+
+	t.Run("radix", func(t *testing.T) {
+		got, ok := ParseNumber("ff", 16)
+		assert(t, true, ok)
+		assert(t, 255, got)
+	})
+
+	for _, in := range []string{``, `+`, `-`, `.`, `...`, `1/`, `/2`, `1/0`, `1/-2`, `1.5/2`, `1e`, `e1`, `--1`, `#x1.5`, `#b2`, `#e+inf.0`, `#e#e1`, `#z1`, `1a`, `0x10`, `inf`} {
+		t.Run("not a number "+in, func(t *testing.T) {
+			_, ok := ParseNumber(in, 10)
+			assert(t, false, ok)
+		})
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	cases := []struct {
+		in    Expr
+		radix int
+		want  string
+	}{
+		{in: 42, radix: 10, want: "42"},
+		{in: -255, radix: 16, want: "-ff"},
+		{in: big.NewRat(-1, 3), radix: 10, want: "-1/3"},
+		{in: 1.0, radix: 10, want: "1.0"},
+		{in: 0.25, radix: 10, want: "0.25"},
+		{in: 1e21, radix: 10, want: "1e21"},
+		{in: 1.5e-7, radix: 10, want: "1.5e-07"},
+		{in: math.Inf(-1), radix: 10, want: "-inf.0"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.want, func(t *testing.T) {
+			assert(t, tt.want, FormatNumber(tt.in, tt.radix))
+		})
+	}
+}
+
+func TestNumberCanonicalForm(t *testing.T) {
+	assert(t, 5, Integer(big.NewInt(5)))
+	assert(t, 2, Rational(big.NewRat(4, 2)))
+	assert(t, true, Equal(big.NewRat(1, 2), big.NewRat(2, 4)))
+	assert(t, false, Equal(1, 1.0))
+}
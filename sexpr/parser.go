@@ -1,121 +1,306 @@
 package sexpr
 
 import (
-	"strconv"
-	"strings"
-	"unicode"
+	"fmt"
+	"unicode/utf8"
 )
 
-const whitespace = " \n\t"
+const whitespace = " \n\t\r\f"
 
-func parseInt(s string) (value Expr, remains string, ok bool) {
-	sign := 1
-	signStr, s, _ := oneOf(matchString("+"), matchString("-"))(s)
-	if signStr == "-" {
-		sign = -1
+// parser reads datums from tokens of the source text. It holds the whole
+// text, so position of any offset in it can be found.
+type parser struct {
+	src string
+	// offset is the byte offset of the next token.
+	offset int
+	// lookahead is the token which is already lexed but not consumed when
+	// hasLookahead is set.
+	lookahead    token
+	hasLookahead bool
+	lines        lineIndex
+	// start is the position of src in the whole text, it is not the
+	// beginning when src is a part of stream.
+	start Pos
+	// recordNodes is set when positions of datums are recorded.
+	recordNodes bool
+	// elements is a stack of elements of sequences being read, it is shared
+	// by all of them to allocate less.
+	elements []Expr
+}
+
+func newParser(src string, recordNodes bool) *parser {
+	return &parser{
+		src:         src,
+		lines:       lineIndex{src: src},
+		start:       Pos{Line: 1, Column: 1},
+		recordNodes: recordNodes,
 	}
-	var accum string
-	for _, c := range []rune(s) {
-		if !unicode.IsDigit(c) {
-			break
-		}
-		accum = accum + string(c)
+}
+
+func (p *parser) pos(offset int) Pos {
+	return p.start.shift(p.lines.pos(offset))
+}
+
+func (p *parser) errorAt(offset int, format string, args ...interface{}) error {
+	return &ParseError{
+		Pos:    p.pos(offset),
+		Reason: fmt.Sprintf(format, args...),
 	}
-	if accum == "" {
-		return 0, s, false
+}
+
+// incompleteAt reports error caused by the end of input, it may disappear
+// when more input follows.
+func (p *parser) incompleteAt(offset int, format string, args ...interface{}) error {
+	err := p.errorAt(offset, format, args...).(*ParseError)
+	err.incomplete = true
+	return err
+}
+
+// unexpected reports that no datum starts at offset.
+func (p *parser) unexpected(offset int) error {
+	if offset == len(p.src) {
+		return p.incompleteAt(offset, "unexpected end of input")
 	}
+	r, _ := utf8.DecodeRuneInString(p.src[offset:])
+	return p.errorAt(offset, "unexpected %q", string(r))
+}
 
-	n, err := strconv.Atoi(accum)
-	if err != nil {
-		return 0, s, false
+// quotedEnd returns length of string or |symbol| at the beginning of s, or -1
+// if it is not terminated.
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case s[0]:
+			return i + 1
+		}
 	}
-	return sign * n, s[len(accum):], true
+	return -1
 }
 
-func parseSymbol(s string) (value Expr, remains string, ok bool) {
-	const allowedSymbolChars = "><!+_-*=?/abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	var accum string
-	for _, c := range []rune(s) {
-		if !strings.ContainsRune(allowedSymbolChars, c) {
-			break
+// token consumes the next token, datum comments are skipped together with
+// the datum they comment out.
+func (p *parser) token() (token, error) {
+	if p.hasLookahead {
+		p.hasLookahead = false
+		return p.lookahead, nil
+	}
+	for {
+		t, err := p.lex()
+		if err != nil || t.kind != tokenDatumComment {
+			return t, err
+		}
+		if _, _, err := p.datum(); err != nil {
+			return t, err
 		}
-		ok = true
-		accum = accum + string(c)
 	}
-	return Symbol(accum), s[len(accum):], ok
 }
 
-func parseBool(s string) (value Expr, remains string, ok bool) {
-	value, remains, ok = oneOf(
-		matchString("#t"),
-		matchString("#f"),
-	)(s)
-	return value == "#t", remains, ok
+// peek returns the next token without consuming it.
+func (p *parser) peek() (token, error) {
+	t, err := p.token()
+	if err == nil {
+		p.lookahead, p.hasLookahead = t, true
+	}
+	return t, err
 }
 
-func parseString(s string) (value Expr, remains string, ok bool) {
-	var accum string
-	s, ok = skipRune(s, `"`)
-	if !ok {
-		return
+// node makes node of datum if positions are recorded.
+func (p *parser) node(value Expr, start int, end int, children []*Node) *Node {
+	if !p.recordNodes {
+		return nil
 	}
-	for _, c := range []rune(s) {
-		if c == '"' {
-			break
+	return &Node{
+		Expr:     value,
+		Span:     Span{Start: p.pos(start), End: p.pos(end)},
+		Children: children,
+	}
+}
+
+// quotePrefixes are abbreviations of quotation forms.
+var quotePrefixes = []struct {
+	prefix string
+	symbol Symbol
+}{
+	{",@", Symbol("unquote-splicing")},
+	{"'", Symbol("quote")},
+	{"`", Symbol("quasiquote")},
+	{",", Symbol("unquote")},
+}
+
+// datum reads the next datum, node of it is nil unless positions are
+// recorded.
+func (p *parser) datum() (Expr, *Node, error) {
+	t, err := p.token()
+	if err != nil {
+		return nil, nil, err
+	}
+	switch t.kind {
+	case tokenAtom:
+		return t.value, p.node(t.value, t.start, t.end, nil), nil
+	case tokenQuote:
+		quoted, child, err := p.datum()
+		if err != nil {
+			return nil, nil, err
+		}
+		var symbol Symbol
+		for _, quote := range quotePrefixes {
+			if quote.prefix == t.text {
+				symbol = quote.symbol
+			}
 		}
-		ok = true
-		accum = accum + string(c)
+		value := List(symbol, quoted)
+		return value, p.node(value, t.start, p.offset, appendNode(nil, child)), nil
+	case tokenOpen:
+		return p.sequence(t)
 	}
-	remains = s[1+len(accum):]
-	return accum, remains, ok
+	return nil, nil, p.unexpected(t.start)
 }
 
-// use sepBy
-func parseList(s string) (value Expr, remains string, ok bool) {
-	remains, ok = skipRune(s, "(")
-	if !ok {
-		return
+func appendNode(nodes []*Node, node *Node) []*Node {
+	if node == nil {
+		return nodes
 	}
+	return append(nodes, node)
+}
 
-	var list []Expr
+// sequence reads elements of list, vector or bytevector after the opening
+// token up to the closing paren.
+func (p *parser) sequence(open token) (Expr, *Node, error) {
+	base := len(p.elements)
+	defer func() { p.elements = p.elements[:base] }()
+	var children []*Node
+	var tail Expr = Nil
 	for {
-		s, _ = skipManyRune(s, whitespace)
-		value, remains, ok = Parse(remains)
-		if !ok {
+		t, err := p.peek()
+		if err != nil {
+			return nil, nil, err
+		}
+		if t.kind == tokenClose || t.kind == tokenEOF {
 			break
 		}
-		list = append(list, value)
 
-		// optional space between elements (not for last element)
-		// FIXME: many spaces
-		remains, ok = skipManyRune(remains, whitespace)
-		if !ok {
+		// dotted tail: (a b . c)
+		if t.kind == tokenDot && open.text == "(" && len(p.elements) > base {
+			p.hasLookahead = false
+			var child *Node
+			tail, child, err = p.datum()
+			if err != nil {
+				return nil, nil, err
+			}
+			children = appendNode(children, child)
+			t, err = p.peek()
+			if err != nil {
+				return nil, nil, err
+			}
+			if t.kind != tokenClose && t.kind != tokenEOF {
+				return nil, nil, p.unexpected(t.start)
+			}
 			break
 		}
+
+		element, child, err := p.datum()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.elements = append(p.elements, element)
+		children = appendNode(children, child)
 	}
+	elements := p.elements[base:]
 
-	remains, ok = skipRune(remains, ")")
-	if !ok {
-		return
+	closing, _ := p.token()
+	if closing.kind == tokenEOF {
+		name := map[string]string{"(": "list", "#(": "vector", "#u8(": "bytevector"}[open.text]
+		return nil, nil, p.incompleteAt(closing.start, "unclosed %s opened at %s", name, p.pos(open.start))
 	}
-	return list, remains, ok
+
+	var value Expr
+	switch open.text {
+	case "#(":
+		value = &Vector{Elements: append([]Expr(nil), elements...)}
+	case "#u8(":
+		bytes := make([]byte, len(elements))
+		for i, element := range elements {
+			b, ok := element.(int)
+			if !ok || b < 0 || b > 255 {
+				return nil, nil, p.errorAt(open.start, "invalid byte %s in bytevector", Print(element))
+			}
+			bytes[i] = byte(b)
+		}
+		value = &Bytevector{Bytes: bytes}
+	default:
+		value = ListWithTail(elements, tail)
+	}
+	return value, p.node(value, open.start, closing.end, children), nil
 }
 
-func parseQuotedExpr(s string) (value Expr, remains string, ok bool) {
-	s, ok = skipRune(s, `'`)
-	if !ok {
-		return
+// skipAtmosphere skips whitespace and comments including datum comments.
+func (p *parser) skipAtmosphere() error {
+	for {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		start := p.offset
+		t, err := p.lex()
+		if err != nil {
+			return err
+		}
+		if t.kind != tokenDatumComment {
+			p.offset = start
+			return nil
+		}
+		if _, _, err := p.datum(); err != nil {
+			return err
+		}
 	}
-	innerExpr, remains, ok := Parse(s)
-	if !ok {
-		return nil, s, false
+}
+
+// Read reads the first datum of s. On failure err is *ParseError.
+func Read(s string) (value Expr, remains string, err error) {
+	p := newParser(s, false)
+	value, _, err = p.datum()
+	if err != nil {
+		return nil, s, err
+	}
+	return value, s[p.offset:], nil
+}
+
+// ReadAt reads datum which starts at byte offset of s and returns offset right
+// after it. Positions in errors are relative to the beginning of s, so it is
+// suitable for reading consecutive datums of one text.
+func ReadAt(s string, offset int) (value Expr, next int, err error) {
+	p := newParser(s, false)
+	p.offset = offset
+	value, _, err = p.datum()
+	if err != nil {
+		return nil, offset, err
+	}
+	return value, p.offset, nil
+}
+
+// HasDatum reports whether s contains anything except whitespace and
+// comments.
+func HasDatum(s string) bool {
+	p := newParser(s, false)
+	err := p.skipAtmosphere()
+	return err != nil || p.offset < len(s)
+}
+
+// ReadNode reads the first datum of s and records the span of it and of every
+// datum nested in it.
+func ReadNode(s string) (node *Node, remains string, err error) {
+	p := newParser(s, true)
+	_, node, err = p.datum()
+	if err != nil {
+		return nil, s, err
 	}
-	return List(Symbol("quote"), innerExpr), remains, true
+	return node, s[p.offset:], nil
 }
 
 func Parse(s string) (value Expr, remains string, ok bool) {
-	s, _ = skipManyRune(s, whitespace)
-	return oneOf(parseInt, parseQuotedExpr, parseSymbol, parseBool, parseString, parseList)(s)
+	value, remains, err := Read(s)
+	return value, remains, err == nil
 }
 
 func MustParse(s string) Expr {
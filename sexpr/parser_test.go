@@ -2,6 +2,8 @@ package sexpr
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -32,6 +34,21 @@ func TestParse(t *testing.T) {
 			in:     `""`,
 			result: "",
 		},
+		{
+			name:   "string escapes",
+			in:     `"a\"b\\c\n\t\x41;\x3bb;"`,
+			result: "a\"b\\c\n\tAλ",
+		},
+		{
+			name:   "string line continuation",
+			in:     "\"one \\  \n   two\"",
+			result: "one two",
+		},
+		{
+			name:   "string with newline",
+			in:     "\"one\ntwo\"",
+			result: "one\ntwo",
+		},
 		{
 			name:   "operator +",
 			in:     `+`,
@@ -46,6 +63,11 @@ func TestParse(t *testing.T) {
 			in:     `foo`,
 			result: Symbol("foo"),
 		},
+		{
+			name:   "ellipsis",
+			in:     `(a ...)`,
+			result: List(Symbol("a"), Symbol("...")),
+		},
 		{
 			in:     `'foo1`,
 			result: List(Symbol("quote"), Symbol("foo1")),
@@ -109,6 +131,113 @@ func TestParse(t *testing.T) {
 			in:     "\t (  1    2\t \t3  \t )\t ",
 			result: List(1, 2, 3),
 		},
+		{
+			in:     `(1 . 2)`,
+			result: Cons(1, 2),
+		},
+		{
+			in:     `(1 2 . 3)`,
+			result: Cons(1, Cons(2, 3)),
+		},
+		{
+			name:   "dotted tail is a list",
+			in:     `(1 . (2 3))`,
+			result: List(1, 2, 3),
+		},
+		{
+			name:   "dotted tail without spaces around",
+			in:     `((1).(2))`,
+			result: Cons(List(1), List(2)),
+		},
+		{
+			in:     `'(a . b)`,
+			result: List(Symbol("quote"), Cons(Symbol("a"), Symbol("b"))),
+		},
+		{
+			name: "quasiquote",
+			in:   "`(a ,b ,@c)",
+			result: List(Symbol("quasiquote"), List(
+				Symbol("a"),
+				List(Symbol("unquote"), Symbol("b")),
+				List(Symbol("unquote-splicing"), Symbol("c")),
+			)),
+		},
+		{
+			name:   "unquote in dotted tail",
+			in:     "`(a . ,b)",
+			result: List(Symbol("quasiquote"), Cons(Symbol("a"), List(Symbol("unquote"), Symbol("b")))),
+		},
+		{
+			name:   "line comments",
+			in:     "; leading\n(1 ; one\n 2);trailing",
+			result: List(1, 2),
+		},
+		{
+			name:   "comment terminates symbol",
+			in:     "(foo;comment\n)",
+			result: List(Symbol("foo")),
+		},
+		{
+			name:   "nested block comments",
+			in:     "(1 #| 2 #| 3 |# 4 |# 5)",
+			result: List(1, 5),
+		},
+		{
+			name:   "datum comments",
+			in:     "(1 #;(2 3) #; #;4 5 6 #;7)",
+			result: List(1, 6),
+		},
+		{
+			name:   "comment before dotted tail",
+			in:     "(1 #;x . #|tail|# 2)",
+			result: Cons(1, 2),
+		},
+		{
+			in:     `(a #!optional b)`,
+			result: List(Symbol("a"), Symbol("#!optional"), Symbol("b")),
+		},
+		{
+			name:   "characters",
+			in:     `(#\a #\A #\( #\) #\; #\space #\newline #\x3bb #\λ)`,
+			result: List(Char('a'), Char('A'), Char('('), Char(')'), Char(';'), Char(' '), Char('\n'), Char('λ'), Char('λ')),
+		},
+		{
+			name:   "character before closing paren",
+			in:     `(#\x)`,
+			result: List(Char('x')),
+		},
+		{
+			name:   "vectors",
+			in:     `#(1 #(a) () "s")`,
+			result: &Vector{Elements: []Expr{1, &Vector{Elements: []Expr{Symbol("a")}}, List(), "s"}},
+		},
+		{
+			in:     `#()`,
+			result: &Vector{},
+		},
+		{
+			in:     `#u8(0 #;1 255)`,
+			result: &Bytevector{Bytes: []byte{0, 255}},
+		},
+		{
+			name: "identifiers",
+			in:   `(string->list a.b %internal $x &rest ~ :keyword λ ünïcødé x1+ <=? ->x -> +- .. +.x .a)`,
+			result: List(
+				Symbol("string->list"), Symbol("a.b"), Symbol("%internal"), Symbol("$x"),
+				Symbol("&rest"), Symbol("~"), Symbol(":keyword"), Symbol("λ"), Symbol("ünïcødé"),
+				Symbol("x1+"), Symbol("<=?"), Symbol("->x"), Symbol("->"), Symbol("+-"),
+				Symbol(".."), Symbol("+.x"), Symbol(".a"),
+			),
+		},
+		{
+			name:   "symbol in vertical lines",
+			in:     `(|hello world| || |a\|b\x41;| x|y|)`,
+			result: List(Symbol("hello world"), Symbol(""), Symbol("a|bA"), Symbol("x"), Symbol("y")),
+		},
+		{
+			in:     `width:`,
+			result: Symbol("width:"),
+		},
 		{
 			name:    "unclosed list",
 			in:      "( ( 1 2 3 )",
@@ -129,9 +258,120 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestReadErrors(t *testing.T) {
+	cases := []struct {
+		in  string
+		err string
+	}{
+		{in: ``, err: `1:1: unexpected end of input`},
+		{in: "  \n ", err: `2:2: unexpected end of input`},
+		{in: `)`, err: `1:1: unexpected ")"`},
+		{in: `"foo`, err: `1:1: unterminated string`},
+		{in: `"foo\"`, err: `1:1: unterminated string`},
+		{in: `("a\qb")`, err: `1:4: invalid escape "\\q"`},
+		{in: `"\x41"`, err: `1:2: unterminated hex escape`},
+		{in: `"\xZZ;"`, err: `1:2: invalid hex escape "\\xZZ;"`},
+		{in: "(1\n  (2 \"3)\n 4", err: `2:6: unterminated string`},
+		{in: "\n\n  (1\n  (2 3)\n", err: `5:1: unclosed list opened at 3:3`},
+		{in: `(. 2)`, err: `1:2: unexpected "."`},
+		{in: `(1 . )`, err: `1:6: unexpected ")"`},
+		{in: `(1 . 2 3)`, err: `1:8: unexpected "3"`},
+		{in: `'`, err: `1:2: unexpected end of input`},
+		{in: "(a ,@)", err: `1:6: unexpected ")"`},
+		{in: `(1a)`, err: `1:2: unexpected "1"`},
+		{in: `(#q)`, err: `1:2: unexpected "#"`},
+		{in: `(|a b)`, err: `1:2: unterminated symbol`},
+		{in: `|a\`, err: `1:1: unterminated symbol`},
+		{in: "(\"λλ\" [)", err: `1:7: unexpected "["`},
+		{in: "(1 #| 2 #| 3 |# )", err: `1:4: unterminated block comment`},
+		{in: "(1 #;)", err: `1:6: unexpected ")"`},
+		{in: `(#\foo)`, err: `1:2: unknown character name "foo"`},
+		{in: `#\`, err: `1:1: unexpected end of input`},
+		{in: `#(1 . 2)`, err: `1:5: unexpected "."`},
+		{in: `#(1 2`, err: `1:6: unclosed vector opened at 1:1`},
+		{in: `#u8(1 256)`, err: `1:1: invalid byte 256 in bytevector`},
+		{in: `#u8(a)`, err: `1:1: invalid byte a in bytevector`},
+		{in: "; only comment", err: `1:15: unexpected end of input`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			_, _, err := Read(tt.in)
+			parseErr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			assert(t, tt.err, parseErr.Error())
+		})
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	src := "(a)\n  b\n (c"
+
+	value, next, err := ReadAt(src, 3)
+	assert(t, nil, err)
+	assert(t, Symbol("b"), value)
+	assert(t, 7, next)
+
+	_, _, err = ReadAt(src, next)
+	assert(t, "3:4: unclosed list opened at 3:2", err.Error())
+}
+
+func TestReadNode(t *testing.T) {
+	node, remains, err := ReadNode("\n (foo\n  '(1 \"bar\")) rest")
+	assert(t, nil, err)
+	assert(t, " rest", remains)
+
+	assert(t, List(Symbol("foo"), List(Symbol("quote"), List(1, "bar"))), node.Expr)
+	assert(t, Span{Start: Pos{Offset: 2, Line: 2, Column: 2}, End: Pos{Offset: 20, Line: 3, Column: 14}}, node.Span)
+	assert(t, 2, len(node.Children))
+
+	foo := node.Children[0]
+	assert(t, Symbol("foo"), foo.Expr)
+	assert(t, Span{Start: Pos{Offset: 3, Line: 2, Column: 3}, End: Pos{Offset: 6, Line: 2, Column: 6}}, foo.Span)
+
+	quoted := node.Children[1]
+	assert(t, Pos{Offset: 9, Line: 3, Column: 3}, quoted.Span.Start)
+	assert(t, 1, len(quoted.Children))
+
+	list := quoted.Children[0]
+	assert(t, Pos{Offset: 10, Line: 3, Column: 4}, list.Span.Start)
+	assert(t, 2, len(list.Children))
+	assert(t, Span{Start: Pos{Offset: 13, Line: 3, Column: 7}, End: Pos{Offset: 18, Line: 3, Column: 12}}, list.Children[1].Span)
+}
+
+func TestHasDatum(t *testing.T) {
+	assert(t, false, HasDatum(""))
+	assert(t, false, HasDatum(" ; comment\n #| block |# #;(datum)"))
+	assert(t, true, HasDatum("; comment\n x"))
+	assert(t, true, HasDatum("#| unterminated"))
+}
+
 func assert(t *testing.T, want, got interface{}) {
 	t.Helper()
 	if !reflect.DeepEqual(want, got) {
 		t.Errorf("not equal want=%+v got=%+v", want, got)
 	}
 }
+
+// benchmarkSource makes source text of about size bytes with datums of all
+// kinds, so reading time can be compared for several sizes.
+func benchmarkSource(size int) string {
+	const datum = `(define (f x) (if (< x 10) '(a "string" #\c 1.5) #(1 2 3))) ; comment` + "\n"
+	return strings.Repeat(datum, size/len(datum))
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, size := range []int{10 << 10, 100 << 10, 1 << 20} {
+		src := "(" + benchmarkSource(size) + ")"
+		b.Run(strconv.Itoa(size>>10)+"KB", func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			for i := 0; i < b.N; i++ {
+				if _, _, ok := Parse(src); !ok {
+					b.Fatal("parsing failed")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package sexpr
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Pos is a position in the source text.
+type Pos struct {
+	// Offset is a byte offset starting from 0.
+	Offset int
+	// Line starts from 1.
+	Line int
+	// Column is counted in runes starting from 1.
+	Column int
+}
+
+func (pos Pos) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
+
+// shift converts position in text which starts at pos into position in the
+// text containing it.
+func (pos Pos) shift(relative Pos) Pos {
+	if relative.Line == 1 {
+		relative.Column += pos.Column - 1
+	}
+	relative.Line += pos.Line - 1
+	relative.Offset += pos.Offset
+	return relative
+}
+
+// Span is a part of the source text occupied by datum, End points right after
+// the last character of datum.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// Node is a datum annotated with its location in the source text.
+type Node struct {
+	Expr Expr
+	Span Span
+	// Children are nodes of datums written inside this one (elements of list
+	// or datum after quote), in order of appearance.
+	Children []*Node
+}
+
+// ParseError describes why and where the reader gave up.
+type ParseError struct {
+	Pos    Pos
+	Reason string
+	// incomplete is set when the input ends too early.
+	incomplete bool
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Pos, err.Reason)
+}
+
+// lineIndex converts byte offsets to line and column.
+type lineIndex struct {
+	src string
+	// starts are offsets of beginnings of lines, computed lazily.
+	starts []int
+}
+
+func (index *lineIndex) pos(offset int) Pos {
+	if index.starts == nil {
+		index.starts = []int{0}
+		for i := 0; i < len(index.src); i++ {
+			if index.src[i] == '\n' {
+				index.starts = append(index.starts, i+1)
+			}
+		}
+	}
+	line := sort.Search(len(index.starts), func(i int) bool {
+		return index.starts[i] > offset
+	}) - 1
+	lineStart := index.starts[line]
+	return Pos{
+		Offset: offset,
+		Line:   line + 1,
+		Column: utf8.RuneCountInString(index.src[lineStart:offset]) + 1,
+	}
+}
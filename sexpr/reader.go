@@ -0,0 +1,156 @@
+package sexpr
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Reader reads datums one at a time from io.Reader, for example from a file,
+// a network connection or a terminal. Input is consumed line by line, so a
+// datum is returned as soon as the line which completes it is available.
+type Reader struct {
+	in *bufio.Reader
+	// buffer accumulates lines of input, text is its content.
+	buffer strings.Builder
+	text   string
+	// offset is the length of text which is already read.
+	offset int
+	// pos is the position of text[offset:] in the whole input.
+	pos Pos
+	// scanned is the offset in text up to which tokens are already looked
+	// through while waiting for the end of datum, depth is the number of
+	// lists open before it.
+	scanned int
+	depth   int
+	// err is the error of the last read from in, io.EOF at the end.
+	err error
+}
+
+func NewReader(in io.Reader) *Reader {
+	return &Reader{
+		in:  bufio.NewReader(in),
+		pos: Pos{Line: 1, Column: 1},
+	}
+}
+
+// Read reads the next datum. It returns io.EOF when the input ends and there
+// are no more datums. Syntax errors are returned as *ParseError with position
+// in the whole input, after them the rest of the current line is skipped, so
+// reading may continue.
+func (r *Reader) Read() (Expr, error) {
+	value, _, err := r.read(false)
+	return value, err
+}
+
+// ReadNode reads the next datum like Read and records spans of it and of
+// every datum nested in it.
+func (r *Reader) ReadNode() (*Node, error) {
+	_, node, err := r.read(true)
+	return node, err
+}
+
+// Pos returns position of the input which is not read yet.
+func (r *Reader) Pos() Pos {
+	return r.pos
+}
+
+func (r *Reader) read(recordNodes bool) (Expr, *Node, error) {
+	for {
+		if r.err == nil && !r.mayBeComplete() {
+			r.fill()
+			continue
+		}
+
+		src := r.text[r.offset:]
+		p := newParser(src, false)
+		p.start = r.pos
+		value, _, err := p.datum()
+		if err == nil {
+			var node *Node
+			if recordNodes {
+				// positions are found only within the datum
+				p = newParser(src[:p.offset], true)
+				p.start = r.pos
+				_, node, _ = p.datum()
+			}
+			r.advance(p.offset)
+			return value, node, nil
+		}
+
+		parseErr, ok := err.(*ParseError)
+		if ok && parseErr.incomplete && r.err == nil {
+			// the datum continues on the next line
+			r.fill()
+			continue
+		}
+		if r.err != nil && r.err != io.EOF {
+			return nil, nil, r.err
+		}
+		hasDatum := HasDatum(src)
+		// skip the rest of the erroneous line
+		r.advance(len(src))
+		if !hasDatum {
+			return nil, nil, io.EOF
+		}
+		return nil, nil, err
+	}
+}
+
+// mayBeComplete looks through tokens of the text added since the last call
+// and reports whether there may be a complete datum, so it is worth reading.
+// It keeps reading of a long datum linear as every line is lexed once.
+func (r *Reader) mayBeComplete() bool {
+	p := newParser(r.text[r.scanned:], false)
+	for {
+		start := p.offset
+		t, err := p.lex()
+		if err != nil {
+			parseErr, ok := err.(*ParseError)
+			if ok && parseErr.incomplete {
+				// lex the unfinished token again when more input comes
+				r.scanned += start
+				return false
+			}
+			return true
+		}
+		switch t.kind {
+		case tokenEOF:
+			r.scanned += t.start
+			return false
+		case tokenQuote, tokenDatumComment:
+			continue
+		case tokenOpen:
+			r.depth++
+		case tokenClose:
+			r.depth--
+		}
+		if r.depth <= 0 {
+			r.scanned += p.offset
+			return true
+		}
+	}
+}
+
+// fill appends the next line of input to the text.
+func (r *Reader) fill() {
+	if r.offset > 0 {
+		rest := r.text[r.offset:]
+		r.buffer.Reset()
+		r.buffer.WriteString(rest)
+		r.scanned -= r.offset
+		r.offset = 0
+	}
+	line, err := r.in.ReadString('\n')
+	r.buffer.WriteString(line)
+	r.text = r.buffer.String()
+	r.err = err
+}
+
+// advance marks n bytes of text as read.
+func (r *Reader) advance(n int) {
+	read := lineIndex{src: r.text[r.offset : r.offset+n]}
+	r.pos = r.pos.shift(read.pos(n))
+	r.offset += n
+	r.scanned, r.depth = r.offset, 0
+}
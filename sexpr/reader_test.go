@@ -0,0 +1,97 @@
+package sexpr
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader("(a\n b) ; comment\n \"multi\nline\" 42\n#| block\n|# c"))
+
+	value, err := r.Read()
+	assert(t, nil, err)
+	assert(t, List(Symbol("a"), Symbol("b")), value)
+	assert(t, Pos{Offset: 6, Line: 2, Column: 4}, r.Pos())
+
+	value, err = r.Read()
+	assert(t, nil, err)
+	assert(t, "multi\nline", value)
+
+	value, err = r.Read()
+	assert(t, nil, err)
+	assert(t, 42, value)
+
+	value, err = r.Read()
+	assert(t, nil, err)
+	assert(t, Symbol("c"), value)
+
+	_, err = r.Read()
+	assert(t, io.EOF, err)
+	_, err = r.Read()
+	assert(t, io.EOF, err)
+}
+
+func TestReaderCRLF(t *testing.T) {
+	r := NewReader(strings.NewReader("(define x 1)\r\n(+ 1\r\n 2)\f\r\n"))
+
+	value, err := r.Read()
+	assert(t, nil, err)
+	assert(t, List(Symbol("define"), Symbol("x"), 1), value)
+
+	value, err = r.Read()
+	assert(t, nil, err)
+	assert(t, List(Symbol("+"), 1, 2), value)
+
+	_, err = r.Read()
+	assert(t, io.EOF, err)
+	assert(t, true, IsComplete("(a\r\nb)\r\n"))
+	assert(t, false, HasDatum("\r\n\f"))
+}
+
+func TestReaderErrors(t *testing.T) {
+	r := NewReader(strings.NewReader("1\n(a ]\n(b\n c) (d"))
+
+	value, err := r.Read()
+	assert(t, nil, err)
+	assert(t, 1, value)
+
+	_, err = r.Read()
+	assert(t, `2:4: unexpected "]"`, err.Error())
+
+	// the rest of erroneous line is skipped
+	value, err = r.Read()
+	assert(t, nil, err)
+	assert(t, List(Symbol("b"), Symbol("c")), value)
+
+	_, err = r.Read()
+	assert(t, `4:7: unclosed list opened at 4:5`, err.Error())
+
+	_, err = r.Read()
+	assert(t, io.EOF, err)
+}
+
+func TestReaderReadNode(t *testing.T) {
+	r := NewReader(strings.NewReader("x\n  (foo\n 1)"))
+
+	_, err := r.Read()
+	assert(t, nil, err)
+
+	node, err := r.ReadNode()
+	assert(t, nil, err)
+	assert(t, List(Symbol("foo"), 1), node.Expr)
+	assert(t, Span{Start: Pos{Offset: 4, Line: 2, Column: 3}, End: Pos{Offset: 12, Line: 3, Column: 4}}, node.Span)
+	assert(t, Pos{Offset: 10, Line: 3, Column: 2}, node.Children[1].Span.Start)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestReaderInputError(t *testing.T) {
+	_, err := NewReader(failingReader{}).Read()
+	assert(t, "connection reset", err.Error())
+}
@@ -1,7 +1,10 @@
 package sexpr
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -10,68 +13,411 @@ type Expr interface{}
 
 type Symbol string
 
+// Pair is a cons cell. Lists are chains of pairs linked by Cdr and terminated
+// by Nil, improper lists are terminated by anything else.
+type Pair struct {
+	Car Expr
+	Cdr Expr
+}
+
+// EmptyList is the type of Nil.
+type EmptyList struct{}
+
+// Nil is the empty list.
+var Nil = EmptyList{}
+
+func Cons(car Expr, cdr Expr) *Pair {
+	return &Pair{Car: car, Cdr: cdr}
+}
+
 func List(l ...Expr) Expr {
-	return l
+	return ListWithTail(l, Nil)
+}
+
+// ListWithTail makes list of elements terminated by tail instead of Nil.
+func ListWithTail(elements []Expr, tail Expr) Expr {
+	list := tail
+	for i := len(elements) - 1; i >= 0; i-- {
+		list = Cons(elements[i], list)
+	}
+	return list
+}
+
+// ToSlice returns elements of proper list, ok is false for anything else.
+func ToSlice(e Expr) (elements []Expr, ok bool) {
+	length, ok := Length(e)
+	if !ok || length == 0 {
+		return nil, ok
+	}
+	elements = make([]Expr, length)
+	for i := range elements {
+		pair := e.(*Pair)
+		elements[i] = pair.Car
+		e = pair.Cdr
+	}
+	return elements, true
+}
+
+// Length returns the number of elements of proper list, ok is false for
+// anything else.
+func Length(e Expr) (length int, ok bool) {
+	for {
+		switch value := e.(type) {
+		case EmptyList:
+			return length, true
+		case *Pair:
+			length++
+			e = value.Cdr
+		default:
+			return 0, false
+		}
+	}
 }
 
+// IsList reports whether e is a proper list, circular lists are not proper.
+func IsList(e Expr) bool {
+	slow, fast := e, e
+	for {
+		pair, ok := fast.(*Pair)
+		if !ok {
+			return fast == Nil
+		}
+		fast = pair.Cdr
+		pair, ok = fast.(*Pair)
+		if !ok {
+			return fast == Nil
+		}
+		fast = pair.Cdr
+		slow = slow.(*Pair).Cdr
+		if slow == fast {
+			return false
+		}
+	}
+}
+
+// IsComplete reports whether s has a datum and all its lists are closed, so
+// it can be read. Comments are ignored.
 func IsComplete(s string) bool {
 	bracesBalance := 0
-	isString := false
 	hasSexpr := false
-	for _, c := range []rune(s) {
-		if !strings.ContainsRune(whitespace, c) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ';':
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return bracesBalance <= 0 && hasSexpr
+			}
+			i += end
+		case strings.HasPrefix(s[i:], "#|"):
+			end := blockCommentEnd(s[i:])
+			if end < 0 {
+				return false
+			}
+			i += end - 1
+		case strings.HasPrefix(s[i:], "#;"):
+			i++
+		case strings.HasPrefix(s[i:], `#\`):
+			// the character may be a paren, a quote or a semicolon
+			i += 2
 			hasSexpr = true
-		}
-		if c == '"' {
-			isString = !isString
-		}
-		if c == '(' && !isString {
+		case c == '"' || c == '|':
+			end := quotedEnd(s[i:])
+			if end < 0 {
+				return false
+			}
+			i += end - 1
+			hasSexpr = true
+		case c == '(':
 			bracesBalance++
-		}
-		if c == ')' && !isString {
+			hasSexpr = true
+		case c == ')':
 			bracesBalance--
+		case strings.IndexByte(whitespace, c) < 0:
+			hasSexpr = true
 		}
 	}
-	return bracesBalance <= 0 && hasSexpr && !isString
+	return bracesBalance <= 0 && hasSexpr
 }
 
+// Print prints expression in write style, so it can be read back. Pairs and
+// vectors which contain themselves are printed with datum labels like
+// #0=(1 . #0#).
 func Print(e Expr) string {
+	return printExpr(e, true)
+}
+
+// Display prints expression in display style, strings and characters are
+// printed as is.
+func Display(e Expr) string {
+	return printExpr(e, false)
+}
+
+func printExpr(e Expr, write bool) string {
+	switch e.(type) {
+	case *Pair, *Vector:
+		p := printer{write: write, labels: findCycles(e)}
+		p.print(e)
+		return p.b.String()
+	}
+	return printAtom(e, write)
+}
+
+// printer prints pairs and vectors. Labels are numbers of datum labels of
+// objects which are parts of cycles, -1 until the object is printed.
+type printer struct {
+	b      strings.Builder
+	write  bool
+	labels map[Expr]int
+	next   int
+}
+
+func (p *printer) print(e Expr) {
 	switch value := e.(type) {
-	case int:
-		return strconv.Itoa(value)
+	case *Pair:
+		if p.label(value) {
+			p.printList(value)
+		}
+	case *Vector:
+		if p.label(value) {
+			p.b.WriteString("#(")
+			for i, element := range value.Elements {
+				if i > 0 {
+					p.b.WriteByte(' ')
+				}
+				p.print(element)
+			}
+			p.b.WriteByte(')')
+		}
+	default:
+		p.b.WriteString(printAtom(e, p.write))
+	}
+}
+
+// label prints datum label of e if it has one and reports whether e itself
+// must be printed, it is not when it is printed already.
+func (p *printer) label(e Expr) bool {
+	label, ok := p.labels[e]
+	switch {
+	case !ok:
+		return true
+	case label >= 0:
+		p.b.WriteString("#" + strconv.Itoa(label) + "#")
+		return false
+	}
+	p.labels[e] = p.next
+	p.b.WriteString("#" + strconv.Itoa(p.next) + "=")
+	p.next++
+	return true
+}
+
+func (p *printer) printList(pair *Pair) {
+	if prefix, ok := quotePrefix(pair); ok && !p.labeled(pair.Cdr) {
+		p.b.WriteString(prefix)
+		p.print(pair.Cdr.(*Pair).Car)
+		return
+	}
+	p.b.WriteByte('(')
+	p.print(pair.Car)
+	tail := pair.Cdr
+	for {
+		next, ok := tail.(*Pair)
+		// labeled tail is printed after dot, so the label can refer to it
+		if !ok || p.labeled(next) {
+			break
+		}
+		p.b.WriteByte(' ')
+		p.print(next.Car)
+		tail = next.Cdr
+	}
+	if tail != Nil {
+		p.b.WriteString(" . ")
+		p.print(tail)
+	}
+	p.b.WriteByte(')')
+}
+
+func (p *printer) labeled(e Expr) bool {
+	_, ok := p.labels[e]
+	return ok
+}
+
+// findCycles returns pairs and vectors of e which are reachable from
+// themselves mapped to -1, or nil when there are none.
+func findCycles(e Expr) map[Expr]int {
+	f := cycleFinder{visiting: make(map[Expr]bool)}
+	f.walk(e)
+	return f.cycles
+}
+
+// cycleFinder walks objects in depth first order, visiting are objects on
+// the current path and objects which are walked already.
+type cycleFinder struct {
+	visiting map[Expr]bool
+	cycles   map[Expr]int
+}
+
+func (f *cycleFinder) walk(e Expr) {
+	// cdr is walked by iteration to not recurse on long lists, all pairs of
+	// the chain are on the path until its end
+	var chain []Expr
+loop:
+	for {
+		switch value := e.(type) {
+		case *Pair, *Vector:
+			if visiting, ok := f.visiting[e]; ok {
+				if visiting {
+					if f.cycles == nil {
+						f.cycles = make(map[Expr]int)
+					}
+					f.cycles[e] = -1
+				}
+				break loop
+			}
+			f.visiting[e] = true
+			chain = append(chain, e)
+			if pair, ok := value.(*Pair); ok {
+				f.walk(pair.Car)
+				e = pair.Cdr
+				continue
+			}
+			for _, element := range value.(*Vector).Elements {
+				f.walk(element)
+			}
+		}
+		break
+	}
+	for _, walked := range chain {
+		f.visiting[walked] = false
+	}
+}
+
+func printAtom(e Expr, write bool) string {
+	switch value := e.(type) {
+	case int, *big.Int, *big.Rat, float64:
+		return FormatNumber(value, 10)
 	case string:
-		return `"` + value + `"`
+		if !write {
+			return value
+		}
+		return printString(value)
+	case Char:
+		if !write {
+			return string(value)
+		}
+		return printChar(value)
 	case Symbol:
-		return string(value)
+		if !write {
+			return string(value)
+		}
+		return printSymbol(value)
 	case bool:
 		if value {
 			return "#t"
 		} else {
 			return "#f"
 		}
-	case []Expr:
-		elements := make([]string, 0, len(value))
-		for _, element := range value {
-			elements = append(elements, Print(element))
-		}
-		return "(" + strings.Join(elements, " ") + ")"
+	case EmptyList:
+		return "()"
+	case *Bytevector:
+		return printBytevector(value)
+	case fmt.Stringer:
+		return value.String()
 	default:
 		return fmt.Sprintf("UNKNOWN %t", e)
 	}
 }
 
+// printString prints string literal which reads back as the same string.
+func printString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < ' ' || r == 0x7f {
+				fmt.Fprintf(&b, `\x%x;`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// quotePrefix returns abbreviation of (quote x) and similar forms.
+func quotePrefix(pair *Pair) (string, bool) {
+	arguments, ok := pair.Cdr.(*Pair)
+	if !ok || arguments.Cdr != Nil {
+		return "", false
+	}
+	for _, quote := range quotePrefixes {
+		if pair.Car == quote.symbol {
+			return quote.prefix, true
+		}
+	}
+	return "", false
+}
+
+// Equal reports whether objects are structurally equal, comparison of
+// circular structures terminates too.
 func Equal(one Expr, other Expr) bool {
+	q := equality{budget: equalBudget}
+	return q.equal(one, other)
+}
+
+// equalBudget is the number of compared pairs and vectors after which
+// comparison starts to remember them, so small structures are compared
+// without allocations.
+const equalBudget = 1000
+
+type equality struct {
+	budget int
+	seen   map[[2]Expr]bool
+}
+
+// visit reports whether the objects are being compared or compared already,
+// then they are assumed to be equal.
+func (q *equality) visit(one Expr, other Expr) bool {
+	if q.budget > 0 {
+		q.budget--
+		return false
+	}
+	if q.seen == nil {
+		q.seen = make(map[[2]Expr]bool)
+	}
+	key := [2]Expr{one, other}
+	if q.seen[key] {
+		return true
+	}
+	q.seen[key] = true
+	return false
+}
+
+func (q *equality) equal(one Expr, other Expr) bool {
 	switch value := one.(type) {
-	case int:
-		if _, ok := other.(int); !ok {
-			return false
-		}
-		return value == other.(int)
+	case int, *big.Int, *big.Rat, float64:
+		return numbersEqual(value, other)
 	case string:
 		if _, ok := other.(string); !ok {
 			return false
 		}
 		return value == other.(string)
+	case Char:
+		if _, ok := other.(Char); !ok {
+			return false
+		}
+		return value == other.(Char)
 	case Symbol:
 		if _, ok := other.(Symbol); !ok {
 			return false
@@ -82,21 +428,47 @@ func Equal(one Expr, other Expr) bool {
 			return false
 		}
 		return value == other.(bool)
-	case []Expr:
-		second, ok := other.([]Expr)
-		if !ok {
+	case EmptyList:
+		return other == Nil
+	case *Vector:
+		second, ok := other.(*Vector)
+		if !ok || len(value.Elements) != len(second.Elements) {
 			return false
 		}
-		if len(value) != len(second) {
-			return false
+		if q.visit(value, second) {
+			return true
 		}
-		for i := range value {
-			if !Equal(value[i], second[i]) {
+		for i := range value.Elements {
+			if !q.equal(value.Elements[i], second.Elements[i]) {
 				return false
 			}
 		}
 		return true
+	case *Bytevector:
+		second, ok := other.(*Bytevector)
+		return ok && bytes.Equal(value.Bytes, second.Bytes)
+	case *Pair:
+		// iterate over cdr to not recurse on long lists
+		for {
+			second, ok := other.(*Pair)
+			if !ok {
+				return false
+			}
+			if value == second || q.visit(value, second) {
+				return true
+			}
+			if !q.equal(value.Car, second.Car) {
+				return false
+			}
+			next, ok := value.Cdr.(*Pair)
+			if !ok {
+				return q.equal(value.Cdr, second.Cdr)
+			}
+			value, other = next, second.Cdr
+		}
 	default:
-		return false
+		// other objects, like procedures, are equal only to themselves
+		typ := reflect.TypeOf(one)
+		return typ != nil && typ == reflect.TypeOf(other) && typ.Comparable() && one == other
 	}
 }
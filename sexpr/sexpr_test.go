@@ -0,0 +1,181 @@
+package sexpr
+
+import "testing"
+
+func TestPrint(t *testing.T) {
+	cases := []struct {
+		in   Expr
+		want string
+	}{
+		{in: List(), want: "()"},
+		{in: List(1, "foo", Symbol("bar")), want: `(1 "foo" bar)`},
+		{in: Cons(1, 2), want: "(1 . 2)"},
+		{in: Cons(1, Cons(2, 3)), want: "(1 2 . 3)"},
+		{in: List(Cons(Symbol("a"), 1), Cons(Symbol("b"), List())), want: "((a . 1) (b))"},
+		{in: "say \"hi\"\n\tback\\slash\x01", want: `"say \"hi\"\n\tback\\slash\x1;"`},
+		{in: List(Symbol("quote"), Symbol("a")), want: "'a"},
+		{in: List(Symbol("quasiquote"), List(List(Symbol("unquote"), Symbol("a")), List(Symbol("unquote-splicing"), Symbol("b")))), want: "`(,a ,@b)"},
+		{in: List(Symbol("quote"), Symbol("a"), Symbol("b")), want: "(quote a b)"},
+		{in: List(Symbol("λ"), Symbol("->"), Symbol("..."), Symbol("#!optional")), want: `(λ -> ... #!optional)`},
+		{in: List(Symbol("a b"), Symbol(""), Symbol("1+"), Symbol("+5"), Symbol("+inf.0"), Symbol("."), Symbol("a|\\")), want: `(|a b| || |1+| |+5| |+inf.0| |.| |a\|\\|)`},
+		{in: &Vector{Elements: []Expr{1, "a", &Vector{}}}, want: `#(1 "a" #())`},
+		{in: &Bytevector{Bytes: []byte{0, 255}}, want: `#u8(0 255)`},
+		{in: List(Char('a'), Char(' '), Char('λ'), Char(0x7f), Char(0x80)), want: `(#\a #\space #\λ #\delete #\x80)`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.want, func(t *testing.T) {
+			assert(t, tt.want, Print(tt.in))
+		})
+	}
+}
+
+// cycle returns list of elements whose last pair refers to the pair with
+// index start.
+func cycle(start int, elements ...Expr) *Pair {
+	list := List(elements...).(*Pair)
+	last, target := list, list
+	for i := 0; last.Cdr != Nil; i++ {
+		if i+1 == start {
+			target = last.Cdr.(*Pair)
+		}
+		last = last.Cdr.(*Pair)
+	}
+	last.Cdr = target
+	return list
+}
+
+func TestPrintCycles(t *testing.T) {
+	selfCar := Cons(nil, Nil)
+	selfCar.Car = selfCar
+	vector := &Vector{Elements: []Expr{1, nil}}
+	vector.Elements[1] = vector
+	shared := List(1)
+
+	assert(t, "#0=(1 2 . #0#)", Print(cycle(0, 1, 2)))
+	assert(t, "(0 . #0=(1 2 . #0#))", Print(cycle(1, 0, 1, 2)))
+	assert(t, "#0=(#0#)", Print(selfCar))
+	assert(t, "#0=#(1 #0#)", Print(vector))
+	assert(t, "(#0=(a . #0#) #1=(b . #1#))", Print(List(cycle(0, Symbol("a")), cycle(0, Symbol("b")))))
+	// shared structure without cycles is printed as is
+	assert(t, "((1) (1))", Print(List(shared, shared)))
+	assert(t, `#0=("x" . #0#)`, Print(cycle(0, "x")))
+	assert(t, `#0=(x . #0#)`, Display(cycle(0, "x")))
+}
+
+func TestPrintRoundTrip(t *testing.T) {
+	cases := []Expr{
+		"",
+		"plain",
+		`quote " and backslash \`,
+		"new\nline\ttab\rreturn",
+		"control \x00\x07\x1b\x7f",
+		"unicode λ ☃",
+		List("a\"b", Symbol("c"), List("\\")),
+		&Vector{Elements: []Expr{List(Symbol("quote"), Symbol("a")), Char(')')}},
+		&Bytevector{Bytes: []byte{1, 2}},
+		List(Symbol("with space"), Symbol("new\nline"), Symbol("#foo"), Symbol("λ")),
+		List(Char('('), Char('\n'), Char(0), Char('λ'), Char(0x200b)),
+	}
+
+	for _, in := range cases {
+		t.Run(Print(in), func(t *testing.T) {
+			got, remains, ok := Parse(Print(in))
+			assert(t, true, ok)
+			assert(t, "", remains)
+			assert(t, in, got)
+		})
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	assert(t, `(a "b" #\c)`, Print(List(Symbol("a"), "b", Char('c'))))
+	assert(t, `(a b c)`, Display(List(Symbol("a"), "b", Char('c'))))
+	assert(t, "say \"hi\"\n", Display("say \"hi\"\n"))
+	assert(t, "a b", Display(Symbol("a b")))
+}
+
+func TestEqual(t *testing.T) {
+	assert(t, true, Equal(List(1, 2, 3), List(1, 2, 3)))
+	assert(t, true, Equal(Cons(1, 2), Cons(1, 2)))
+	assert(t, false, Equal(Cons(1, 2), List(1, 2)))
+	assert(t, false, Equal(List(1, 2), List(1, 2, 3)))
+	assert(t, true, Equal(List(), List()))
+	assert(t, false, Equal(List(), List(1)))
+	assert(t, true, Equal(&Vector{Elements: []Expr{1, List(2)}}, &Vector{Elements: []Expr{1, List(2)}}))
+	assert(t, false, Equal(&Vector{Elements: []Expr{1}}, &Vector{Elements: []Expr{1, 2}}))
+	assert(t, true, Equal(&Bytevector{Bytes: []byte{1}}, &Bytevector{Bytes: []byte{1}}))
+	assert(t, false, Equal(&Bytevector{}, &Vector{}))
+	assert(t, true, Equal(Char('a'), Char('a')))
+	assert(t, false, Equal(Char('a'), "a"))
+}
+
+func TestEqualCycles(t *testing.T) {
+	assert(t, true, Equal(cycle(0, 1, 2), cycle(0, 1, 2)))
+	assert(t, true, Equal(cycle(0, 1, 2), cycle(0, 1, 2, 1, 2)))
+	assert(t, false, Equal(cycle(0, 1, 2), cycle(0, 1, 3)))
+	assert(t, false, Equal(cycle(0, 1, 2), List(1, 2, 1, 2)))
+}
+
+func TestIsList(t *testing.T) {
+	assert(t, true, IsList(List()))
+	assert(t, true, IsList(List(1, 2, 3)))
+	assert(t, false, IsList(Cons(1, 2)))
+	assert(t, false, IsList(1))
+
+	circular := Cons(1, Cons(2, nil))
+	circular.Cdr.(*Pair).Cdr = circular
+	assert(t, false, IsList(circular))
+}
+
+func TestToSlice(t *testing.T) {
+	elements, ok := ToSlice(List(1, 2))
+	assert(t, true, ok)
+	assert(t, []Expr{1, 2}, elements)
+
+	_, ok = ToSlice(Cons(1, 2))
+	assert(t, false, ok)
+}
+
+func TestLength(t *testing.T) {
+	length, ok := Length(List(1, 2, 3))
+	assert(t, true, ok)
+	assert(t, 3, length)
+
+	length, ok = Length(List())
+	assert(t, true, ok)
+	assert(t, 0, length)
+
+	_, ok = Length(Cons(1, Cons(2, 3)))
+	assert(t, false, ok)
+}
+
+func TestIsComplete(t *testing.T) {
+	cases := []struct {
+		in   string
+		want bool
+	}{
+		{in: "(+ 1 2)", want: true},
+		{in: "(+ 1", want: false},
+		{in: "", want: false},
+		{in: `"(" 1`, want: true},
+		{in: `("\")")`, want: true},
+		{in: `("\")`, want: false},
+		{in: "; (\n", want: false},
+		{in: "(+ 1 ; )\n", want: false},
+		{in: "(+ 1 ; )\n 2)", want: true},
+		{in: "#| ) |# (a #| #| ( |# |#)", want: true},
+		{in: "(a #| |#", want: false},
+		{in: "a #| unterminated", want: false},
+		{in: "#;(a) b", want: true},
+		{in: "(|a)b|", want: false},
+		{in: `(#\( #\;)`, want: true},
+		{in: `(#\)`, want: false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.in, func(t *testing.T) {
+			assert(t, tt.want, IsComplete(tt.in))
+		})
+	}
+}
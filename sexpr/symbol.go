@@ -0,0 +1,103 @@
+package sexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// specialInitials are ASCII characters besides letters which may start an
+// identifier.
+const specialInitials = "!$%&*/:<=>?^_~"
+
+// unicodeInitials are categories of non-ASCII characters which may start an
+// identifier.
+var unicodeInitials = []*unicode.RangeTable{
+	unicode.Lu, unicode.Ll, unicode.Lt, unicode.Lm, unicode.Lo,
+	unicode.Mn, unicode.Nl, unicode.No,
+	unicode.Pd, unicode.Pc, unicode.Po,
+	unicode.Sc, unicode.Sm, unicode.Sk, unicode.So,
+	unicode.Co,
+}
+
+func isInitial(r rune) bool {
+	if r < utf8.RuneSelf {
+		return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || strings.ContainsRune(specialInitials, r)
+	}
+	// zero width non-joiner and joiner
+	return r == 0x200c || r == 0x200d || unicode.In(r, unicodeInitials...)
+}
+
+func isSubsequent(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isInitial(r) || '0' <= r && r <= '9' || strings.ContainsRune("+-.@", r)
+	}
+	return isInitial(r) || unicode.In(r, unicode.Nd, unicode.Mc, unicode.Me)
+}
+
+func isSignSubsequent(r rune) bool {
+	return isInitial(r) || r == '+' || r == '-' || r == '@'
+}
+
+// isIdentifier reports whether s is written as identifier without vertical
+// lines. Besides ordinary identifiers there are peculiar ones: +, -, and
+// those starting with a sign or a dot like -> or ...
+func isIdentifier(s string) bool {
+	first, size := utf8.DecodeRuneInString(s)
+	if s == "" || !isInitial(first) && first != '+' && first != '-' && first != '.' {
+		return false
+	}
+	for _, r := range s[size:] {
+		if !isSubsequent(r) {
+			return false
+		}
+	}
+	if isInitial(first) || s == "+" || s == "-" {
+		return true
+	}
+
+	rest := s[size:]
+	if first != '.' {
+		second, secondSize := utf8.DecodeRuneInString(rest)
+		if isSignSubsequent(second) {
+			return true
+		}
+		if second != '.' {
+			return false
+		}
+		rest = rest[secondSize:]
+	}
+	// dot subsequent
+	r, _ := utf8.DecodeRuneInString(rest)
+	return rest != "" && (isSignSubsequent(r) || r == '.')
+}
+
+// printSymbol prints symbol in vertical lines unless it reads back as the
+// same symbol without them.
+func printSymbol(symbol Symbol) string {
+	name := string(symbol)
+	if _, isNumber := ParseNumber(name, 10); !isNumber && isIdentifier(name) {
+		return name
+	}
+	// special names like #!optional are read as symbols too
+	if name != "" && hashBangName(name) == name {
+		return name
+	}
+
+	var b strings.Builder
+	b.WriteByte('|')
+	for _, r := range name {
+		switch {
+		case r == '|' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(&b, `\x%x;`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('|')
+	return b.String()
+}
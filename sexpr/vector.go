@@ -0,0 +1,25 @@
+package sexpr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Vector is a fixed length sequence of objects with constant time access by
+// index, it is read as #(1 2 3).
+type Vector struct {
+	Elements []Expr
+}
+
+// Bytevector is a fixed length sequence of bytes, it is read as #u8(0 255).
+type Bytevector struct {
+	Bytes []byte
+}
+
+func printBytevector(v *Bytevector) string {
+	elements := make([]string, len(v.Bytes))
+	for i, b := range v.Bytes {
+		elements[i] = strconv.Itoa(int(b))
+	}
+	return "#u8(" + strings.Join(elements, " ") + ")"
+}